@@ -0,0 +1,254 @@
+// Package analytics computes speaker statistics of Rev.ai transcripts for
+// meeting analytics, such as talk time, words per minute, turn taking and
+// interruptions.
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	revai "github.com/threeaccents/revai-go"
+)
+
+// Options specifies how a transcript is analyzed.
+type Options struct {
+	// Duration is the length of the recording. It defaults to the end of the
+	// last word.
+	Duration time.Duration
+
+	// InterruptionGap is how soon after a turn left unfinished another
+	// speaker must start for it to be an interruption. It defaults to 200
+	// milliseconds.
+	InterruptionGap time.Duration
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{InterruptionGap: 200 * time.Millisecond}
+
+	if o == nil {
+		return opts
+	}
+
+	opts.Duration = o.Duration
+	if o.InterruptionGap > 0 {
+		opts.InterruptionGap = o.InterruptionGap
+	}
+
+	return opts
+}
+
+// Report is the statistics of a transcript. Times are in seconds.
+type Report struct {
+	Duration float64 `json:"duration"`
+	TalkTime float64 `json:"talk_time"`
+	Words    int     `json:"words"`
+	Turns    int     `json:"turns"`
+
+	// Silence is the time of the recording without words and SilenceRatio
+	// its fraction of the recording.
+	Silence      float64 `json:"silence"`
+	SilenceRatio float64 `json:"silence_ratio"`
+
+	Overlaps            int     `json:"overlaps"`
+	Interruptions       int     `json:"interruptions"`
+	MeanResponseLatency float64 `json:"mean_response_latency"`
+
+	// Speakers are the statistics of each speaker ordered by speaker.
+	Speakers []SpeakerStats `json:"speakers"`
+}
+
+// SpeakerStats is the statistics of a speaker. A turn is the consecutive
+// monologues of a speaker until another speaker talks, and its time is from
+// the start of its first word to the end of its last.
+type SpeakerStats struct {
+	Speaker int `json:"speaker"`
+
+	// TalkTime is the time of the speaker's turns and ShareOfVoice its
+	// fraction of the talk time of all speakers.
+	TalkTime       float64 `json:"talk_time"`
+	ShareOfVoice   float64 `json:"share_of_voice"`
+	Words          int     `json:"words"`
+	WordsPerMinute float64 `json:"words_per_minute"`
+
+	Turns            int     `json:"turns"`
+	MeanTurnDuration float64 `json:"mean_turn_duration"`
+	MeanTurnWords    float64 `json:"mean_turn_words"`
+	LongestMonologue Span    `json:"longest_monologue"`
+
+	// Responses is the number of the speaker's turns starting after another
+	// speaker's turn ended, and MeanResponseLatency the mean time between
+	// them. Turns starting before the other speaker finished are counted as
+	// overlaps instead.
+	Responses           int     `json:"responses"`
+	MeanResponseLatency float64 `json:"mean_response_latency"`
+
+	// Overlaps is the number of the speaker's turns starting before the turn
+	// of another speaker ended, and Interruptions the number starting during
+	// or just after another speaker's unfinished sentence. Interrupted is the
+	// number of the speaker's turns interrupted by others.
+	Overlaps      int `json:"overlaps"`
+	Interruptions int `json:"interruptions"`
+	Interrupted   int `json:"interrupted"`
+}
+
+// Span is the time and words of a monologue.
+type Span struct {
+	Monologue int     `json:"monologue"`
+	Ts        float64 `json:"ts"`
+	EndTs     float64 `json:"end_ts"`
+	Words     int     `json:"words"`
+}
+
+// turn is the consecutive monologues of a speaker.
+type turn struct {
+	speaker    int
+	start, end time.Duration
+	words      int
+	finished   bool
+}
+
+// Analyze returns the statistics of the transcript.
+func Analyze(t *revai.Transcript, opts *Options) *Report {
+	o := opts.withDefaults()
+
+	var (
+		turns  []turn
+		speech []revai.TimeRange
+		stats  = map[int]*SpeakerStats{}
+	)
+	speaker := func(s int) *SpeakerStats {
+		if stats[s] == nil {
+			stats[s] = &SpeakerStats{Speaker: s}
+		}
+		return stats[s]
+	}
+
+	for i, monologue := range t.Monologues {
+		r := monologue.TimeRange()
+
+		words := 0
+		for _, element := range monologue.Elements {
+			if element.Type == revai.ElementText {
+				words++
+				speech = append(speech, element.TimeRange())
+			}
+		}
+		if words == 0 {
+			continue
+		}
+
+		s := speaker(monologue.Speaker)
+		s.Words += words
+		if longest := s.LongestMonologue; longest.Words == 0 || r.Duration().Seconds() > longest.EndTs-longest.Ts {
+			s.LongestMonologue = Span{Monologue: i, Ts: r.Start.Seconds(), EndTs: r.End.Seconds(), Words: words}
+		}
+
+		finished := endsSentence(monologue.Elements)
+		if n := len(turns); n > 0 && turns[n-1].speaker == monologue.Speaker {
+			last := &turns[n-1]
+			if r.End > last.end {
+				last.end = r.End
+			}
+			last.words += words
+			last.finished = finished
+			continue
+		}
+		turns = append(turns, turn{speaker: monologue.Speaker, start: r.Start, end: r.End, words: words, finished: finished})
+	}
+
+	report := &Report{Turns: len(turns), Speakers: make([]SpeakerStats, 0, len(stats))}
+
+	var (
+		latency   time.Duration
+		latencies int
+	)
+	for i, tr := range turns {
+		s := speaker(tr.speaker)
+		s.Turns++
+		s.TalkTime += (tr.end - tr.start).Seconds()
+		report.Words += tr.words
+
+		if i == 0 {
+			continue
+		}
+		prev := turns[i-1]
+		gap := tr.start - prev.end
+
+		if gap < 0 {
+			s.Overlaps++
+			report.Overlaps++
+		} else {
+			s.Responses++
+			s.MeanResponseLatency += gap.Seconds()
+			latency += gap
+			latencies++
+		}
+		if !prev.finished && gap <= o.InterruptionGap {
+			s.Interruptions++
+			speaker(prev.speaker).Interrupted++
+			report.Interruptions++
+		}
+	}
+	if latencies > 0 {
+		report.MeanResponseLatency = latency.Seconds() / float64(latencies)
+	}
+
+	for _, s := range stats {
+		report.TalkTime += s.TalkTime
+	}
+	for _, s := range stats {
+		if report.TalkTime > 0 {
+			s.ShareOfVoice = s.TalkTime / report.TalkTime
+		}
+		if s.TalkTime > 0 {
+			s.WordsPerMinute = float64(s.Words) / (s.TalkTime / 60)
+		}
+		if s.Turns > 0 {
+			s.MeanTurnDuration = s.TalkTime / float64(s.Turns)
+			s.MeanTurnWords = float64(s.Words) / float64(s.Turns)
+		}
+		if s.Responses > 0 {
+			s.MeanResponseLatency /= float64(s.Responses)
+		}
+		report.Speakers = append(report.Speakers, *s)
+	}
+	sort.Slice(report.Speakers, func(i, j int) bool { return report.Speakers[i].Speaker < report.Speakers[j].Speaker })
+
+	duration := o.Duration
+	spoken := revai.MergeTimeRanges(speech, 0)
+	if n := len(spoken); duration == 0 && n > 0 {
+		duration = spoken[n-1].End
+	}
+	var talking time.Duration
+	for _, r := range spoken {
+		if r.End > duration {
+			r.End = duration
+		}
+		if r.End > r.Start {
+			talking += r.Duration()
+		}
+	}
+	report.Duration = duration.Seconds()
+	report.Silence = (duration - talking).Seconds()
+	if duration > 0 {
+		report.SilenceRatio = report.Silence / report.Duration
+	}
+
+	return report
+}
+
+// endsSentence reports whether the last punctuation of elements ends a
+// sentence.
+func endsSentence(elements []revai.Element) bool {
+	for i := len(elements) - 1; i >= 0; i-- {
+		element := elements[i]
+		if element.Type != revai.ElementPunct {
+			return false
+		}
+		if value := strings.TrimSpace(element.Value); value != "" {
+			return strings.ContainsAny(value, ".?!")
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	revai "github.com/threeaccents/revai-go"
+)
+
+// monologue returns a monologue of words from ts to end_ts pairs, ending with
+// punct when it is not empty.
+func monologue(speaker int, punct string, words ...interface{}) revai.Monologue {
+	m := revai.Monologue{Speaker: speaker}
+	for i := 0; i < len(words); i += 3 {
+		if i > 0 {
+			m.Elements = append(m.Elements, revai.Element{Type: "punct", Value: " "})
+		}
+		m.Elements = append(m.Elements, revai.Element{Type: "text", Value: words[i].(string), Ts: words[i+1].(float64), EndTs: words[i+2].(float64)})
+	}
+	if punct != "" {
+		m.Elements = append(m.Elements, revai.Element{Type: "punct", Value: punct})
+	}
+	return m
+}
+
+var testTranscript = &revai.Transcript{Monologues: []revai.Monologue{
+	monologue(0, ".", "Hello", 0.0, 0.5, "there", 0.5, 1.0),
+	// speaker 0 cuts off speaker 1 before they finish.
+	monologue(1, "", "Hi", 1.5, 2.0, "how", 2.0, 2.5),
+	monologue(0, ".", "wait", 2.4, 2.8),
+	monologue(0, ".", "okay", 3.0, 3.4),
+	monologue(1, ".", "sure", 4.0, 4.5),
+}}
+
+func TestAnalyze(t *testing.T) {
+	report := Analyze(testTranscript, &Options{Duration: 5 * time.Second})
+
+	assert.Equal(t, 5.0, report.Duration)
+	assert.InDelta(t, 3.5, report.TalkTime, 1e-9)
+	assert.Equal(t, 7, report.Words)
+	assert.Equal(t, 4, report.Turns)
+	assert.InDelta(t, 1.8, report.Silence, 1e-9)
+	assert.InDelta(t, 0.36, report.SilenceRatio, 1e-9)
+	assert.Equal(t, 1, report.Overlaps)
+	assert.Equal(t, 1, report.Interruptions)
+	assert.InDelta(t, 0.55, report.MeanResponseLatency, 1e-9)
+
+	if !assert.Len(t, report.Speakers, 2) {
+		return
+	}
+
+	first, second := report.Speakers[0], report.Speakers[1]
+
+	assert.Equal(t, 0, first.Speaker)
+	assert.InDelta(t, 2.0, first.TalkTime, 1e-9)
+	assert.InDelta(t, 2.0/3.5, first.ShareOfVoice, 1e-9)
+	assert.Equal(t, 4, first.Words)
+	assert.InDelta(t, 120, first.WordsPerMinute, 1e-9)
+	// consecutive monologues of a speaker are one turn.
+	assert.Equal(t, 2, first.Turns)
+	assert.InDelta(t, 1.0, first.MeanTurnDuration, 1e-9)
+	assert.Equal(t, 2.0, first.MeanTurnWords)
+	assert.Equal(t, Span{Monologue: 0, Ts: 0, EndTs: 1, Words: 2}, first.LongestMonologue)
+	assert.Equal(t, 0, first.Responses)
+	assert.Equal(t, 1, first.Overlaps)
+	assert.Equal(t, 1, first.Interruptions)
+	assert.Equal(t, 0, first.Interrupted)
+
+	assert.Equal(t, 1, second.Speaker)
+	assert.InDelta(t, 1.5, second.TalkTime, 1e-9)
+	assert.InDelta(t, 120, second.WordsPerMinute, 1e-9)
+	assert.Equal(t, 2, second.Responses)
+	assert.InDelta(t, 0.55, second.MeanResponseLatency, 1e-9)
+	assert.Equal(t, 0, second.Overlaps)
+	assert.Equal(t, 1, second.Interrupted)
+}
+
+func TestAnalyze_Defaults(t *testing.T) {
+	// the recording ends with the last word.
+	report := Analyze(testTranscript, nil)
+	assert.Equal(t, 4.5, report.Duration)
+	assert.InDelta(t, 1.3, report.Silence, 1e-9)
+
+	// a turn starting soon after an unfinished sentence is an interruption.
+	late := &revai.Transcript{Monologues: []revai.Monologue{
+		monologue(0, "", "so", 0.0, 0.5),
+		monologue(1, ".", "no", 0.6, 1.0),
+	}}
+	assert.Equal(t, 1, Analyze(late, nil).Interruptions)
+	assert.Equal(t, 0, Analyze(late, &Options{InterruptionGap: 50 * time.Millisecond}).Interruptions)
+
+	empty := Analyze(&revai.Transcript{}, nil)
+	assert.Equal(t, 0.0, empty.Duration)
+	assert.Empty(t, empty.Speakers)
+
+	var buf bytes.Buffer
+	assert.NoError(t, json.NewEncoder(&buf).Encode(empty))
+	assert.Contains(t, buf.String(), `"speakers":[]`)
+}
@@ -0,0 +1,226 @@
+package revai
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// annotation is a labelled time interval of an annotation tier, in seconds.
+type annotation struct {
+	start float64
+	end   float64
+	text  string
+}
+
+// speakerAnnotations are the annotations of a single speaker used to read and
+// write linguistic annotation formats. turns are the monologues of the speaker,
+// words its text elements with punctuation attached and phrases the words
+// between punctuation.
+type speakerAnnotations struct {
+	speaker int
+	name    string
+	turns   []annotation
+	phrases []annotation
+	words   []annotation
+}
+
+// speakerAnnotations groups the transcript by speaker in order of speaker number.
+func (t *Transcript) speakerAnnotations(names map[int]string) []speakerAnnotations {
+	bySpeaker := map[int]*speakerAnnotations{}
+	var speakers []int
+
+	for _, monologue := range t.Monologues {
+		sa, ok := bySpeaker[monologue.Speaker]
+		if !ok {
+			name := names[monologue.Speaker]
+			if name == "" {
+				name = "Speaker " + strconv.Itoa(monologue.Speaker)
+			}
+			sa = &speakerAnnotations{speaker: monologue.Speaker, name: name}
+			bySpeaker[monologue.Speaker] = sa
+			speakers = append(speakers, monologue.Speaker)
+		}
+
+		var (
+			words  []annotation
+			phrase []annotation
+		)
+
+		endPhrase := func() {
+			if len(phrase) == 0 {
+				return
+			}
+			sa.phrases = append(sa.phrases, joinAnnotations(phrase))
+			phrase = nil
+		}
+
+		for _, element := range monologue.Elements {
+			switch element.Type {
+			case ElementText:
+				if strings.TrimSpace(element.Value) == "" {
+					continue
+				}
+				w := annotation{start: element.Ts, end: element.EndTs, text: element.Value}
+				words = append(words, w)
+				phrase = append(phrase, w)
+			case ElementPunct:
+				value := strings.TrimSpace(element.Value)
+				if value == "" || len(words) == 0 {
+					continue
+				}
+				words[len(words)-1].text += value
+				if len(phrase) > 0 {
+					phrase[len(phrase)-1].text += value
+				}
+				endPhrase()
+			}
+		}
+		endPhrase()
+
+		if len(words) == 0 {
+			continue
+		}
+
+		sa.words = append(sa.words, words...)
+		sa.turns = append(sa.turns, joinAnnotations(words))
+	}
+
+	sort.Ints(speakers)
+
+	annotations := make([]speakerAnnotations, len(speakers))
+	for i, speaker := range speakers {
+		annotations[i] = *bySpeaker[speaker]
+	}
+
+	return annotations
+}
+
+// joinAnnotations returns an annotation spanning words with their text
+// joined by spaces.
+func joinAnnotations(words []annotation) annotation {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.text
+	}
+	return annotation{start: words[0].start, end: words[len(words)-1].end, text: strings.Join(texts, " ")}
+}
+
+// annotationsTranscript creates a transcript from speaker annotations. Each
+// turn becomes a monologue made of the words inside it, or of its text split
+// evenly over its interval when there are no words.
+func annotationsTranscript(speakers []speakerAnnotations) *Transcript {
+	t := &Transcript{}
+
+	for _, sa := range speakers {
+		for _, turn := range sa.turns {
+			if strings.TrimSpace(turn.text) == "" {
+				continue
+			}
+
+			var words []annotation
+			for _, w := range sa.words {
+				if mid := (w.start + w.end) / 2; mid >= turn.start && mid <= turn.end && strings.TrimSpace(w.text) != "" {
+					words = append(words, w)
+				}
+			}
+			if len(words) == 0 {
+				words = splitAnnotation(turn)
+			}
+
+			t.Monologues = append(t.Monologues, Monologue{
+				Speaker:  sa.speaker,
+				Elements: annotationElements(words),
+			})
+		}
+	}
+
+	sort.SliceStable(t.Monologues, func(i, j int) bool {
+		return t.Monologues[i].Elements[0].Ts < t.Monologues[j].Elements[0].Ts
+	})
+
+	return t
+}
+
+// splitAnnotation splits a into one annotation per word, sharing its interval
+// evenly. It returns nil when a has no words.
+func splitAnnotation(a annotation) []annotation {
+	fields := strings.Fields(a.text)
+	if len(fields) == 0 {
+		return nil
+	}
+	step := (a.end - a.start) / float64(len(fields))
+
+	words := make([]annotation, len(fields))
+	for i, field := range fields {
+		words[i] = annotation{start: a.start + float64(i)*step, end: a.start + float64(i+1)*step, text: field}
+	}
+	words[len(words)-1].end = a.end
+
+	return words
+}
+
+// annotationElements converts words into text elements separated by spaces,
+// with trailing punctuation split into punct elements.
+func annotationElements(words []annotation) []Element {
+	var elements []Element
+
+	for i, w := range words {
+		if i > 0 {
+			elements = append(elements, Element{Type: ElementPunct, Value: " "})
+		}
+
+		text := strings.TrimSpace(w.text)
+		value := strings.TrimRightFunc(text, unicode.IsPunct)
+		if value == "" {
+			value = text
+		}
+
+		elements = append(elements, Element{Type: ElementText, Value: value, Ts: w.start, EndTs: w.end, Confidence: 1})
+		if punct := text[len(value):]; punct != "" {
+			elements = append(elements, Element{Type: ElementPunct, Value: punct})
+		}
+	}
+
+	return elements
+}
+
+// speakerNumber returns the speaker number of a tier named "Speaker N".
+func speakerNumber(name string) (int, bool) {
+	if !strings.HasPrefix(name, "Speaker ") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(name, "Speaker "))
+	return n, err == nil && n >= 0
+}
+
+// numberSpeakers sets the speaker of each tier from its name, or to the lowest
+// unused number when it is not named "Speaker N". Custom names are returned.
+func numberSpeakers(speakers []speakerAnnotations) map[int]string {
+	used := map[int]bool{}
+	named := make([]bool, len(speakers))
+	for i := range speakers {
+		if n, ok := speakerNumber(speakers[i].name); ok && !used[n] {
+			speakers[i].speaker = n
+			used[n] = true
+			named[i] = true
+		}
+	}
+
+	names := map[int]string{}
+	next := 0
+	for i := range speakers {
+		if named[i] {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		speakers[i].speaker = next
+		used[next] = true
+		names[next] = speakers[i].name
+	}
+
+	return names
+}
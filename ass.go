@@ -0,0 +1,334 @@
+package revai
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	assPlayResX = 1920
+	assPlayResY = 1080
+)
+
+// assSpeakerColors are the primary colors speakers are styled with when no
+// color is configured, in order of first appearance.
+var assSpeakerColors = []color.Color{
+	color.White,
+	color.RGBA{R: 0xff, G: 0xff, A: 0xff},
+	color.RGBA{G: 0xff, B: 0xff, A: 0xff},
+	color.RGBA{G: 0xff, A: 0xff},
+	color.RGBA{R: 0xff, B: 0xff, A: 0xff},
+}
+
+// ASSStyle is a named style of the [V4+ Styles] section.
+// Zero and nil fields use the value of ASSOptions.DefaultStyle or the built in
+// default.
+type ASSStyle struct {
+	FontName string
+	FontSize int
+
+	// Bold and Italic are left as the default when nil, so an override can
+	// turn them off.
+	Bold   *bool
+	Italic *bool
+
+	PrimaryColor   color.Color
+	SecondaryColor color.Color
+	OutlineColor   color.Color
+	BackColor      color.Color
+
+	// Alignment is the position of the text on screen as laid out on a
+	// numeric keypad, 2 is bottom center.
+	Alignment *int
+
+	// MarginL, MarginR and MarginV are the margins in pixels, left as the
+	// default when nil so an override can set them to 0.
+	MarginL *int
+	MarginR *int
+	MarginV *int
+}
+
+func (s ASSStyle) merge(o *ASSStyle) ASSStyle {
+	if o == nil {
+		return s
+	}
+	if o.FontName != "" {
+		s.FontName = o.FontName
+	}
+	if o.FontSize > 0 {
+		s.FontSize = o.FontSize
+	}
+	if o.Bold != nil {
+		s.Bold = o.Bold
+	}
+	if o.Italic != nil {
+		s.Italic = o.Italic
+	}
+	if o.PrimaryColor != nil {
+		s.PrimaryColor = o.PrimaryColor
+	}
+	if o.SecondaryColor != nil {
+		s.SecondaryColor = o.SecondaryColor
+	}
+	if o.OutlineColor != nil {
+		s.OutlineColor = o.OutlineColor
+	}
+	if o.BackColor != nil {
+		s.BackColor = o.BackColor
+	}
+	if o.Alignment != nil {
+		s.Alignment = o.Alignment
+	}
+	if o.MarginL != nil {
+		s.MarginL = o.MarginL
+	}
+	if o.MarginR != nil {
+		s.MarginR = o.MarginR
+	}
+	if o.MarginV != nil {
+		s.MarginV = o.MarginV
+	}
+	return s
+}
+
+// ASSOptions specifies how EncodeASS writes a transcript as Advanced
+// SubStation Alpha subtitles.
+type ASSOptions struct {
+	// Style is the caption style profile events are segmented with.
+	// It defaults to NetflixCaptionStyle.
+	Style *CaptionStyle
+
+	// Title is written to the [Script Info] section.
+	Title string
+
+	// SpeakerNames maps Monologue.Speaker to the name of its style and the
+	// Name field of its events. Speakers without a name are called "Speaker N".
+	SpeakerNames map[int]string
+
+	// DefaultStyle is applied to every speaker style.
+	DefaultStyle *ASSStyle
+
+	// SpeakerStyles overrides the style of a speaker. Speakers without a
+	// primary color are given one from a fixed palette.
+	SpeakerStyles map[int]ASSStyle
+
+	// Karaoke adds a \k tag before each word so each word is highlighted
+	// as it is spoken.
+	Karaoke bool
+
+	// FrameRate snaps event and word times to its frames when set, and
+	// StartTimecode is added to them, as with SnapCues.
+	FrameRate     FrameRate
+	StartTimecode Timecode
+}
+
+// EncodeASS writes the transcript to w as Advanced SubStation Alpha subtitles.
+// Each speaker is written as its own named style.
+func EncodeASS(w io.Writer, t *Transcript, opts *ASSOptions) error {
+	if opts == nil {
+		opts = &ASSOptions{}
+	}
+
+	segments := reflow(transcriptWords(t), opts.Style.withDefaults())
+	segments = cueTiming{rate: opts.FrameRate, start: opts.StartTimecode}.segments(segments)
+
+	// speakers get a style each, in order of first appearance.
+	styleNames := map[int]string{}
+	used := map[string]bool{}
+	var speakers []int
+	for _, seg := range segments {
+		if _, ok := styleNames[seg.speaker]; ok {
+			continue
+		}
+		name := assEscapeName(opts.speakerName(seg.speaker))
+		for n := 2; used[name]; n++ {
+			name = assEscapeName(opts.speakerName(seg.speaker)) + " " + strconv.Itoa(n)
+		}
+		used[name] = true
+		styleNames[seg.speaker] = name
+		speakers = append(speakers, seg.speaker)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	title := opts.Title
+	if title == "" {
+		title = "Rev.ai transcript"
+	}
+
+	fmt.Fprintf(bw, "[Script Info]\n")
+	fmt.Fprintf(bw, "Title: %s\n", assEscapeField(title))
+	fmt.Fprintf(bw, "ScriptType: v4.00+\n")
+	fmt.Fprintf(bw, "WrapStyle: 0\n")
+	fmt.Fprintf(bw, "ScaledBorderAndShadow: yes\n")
+	fmt.Fprintf(bw, "PlayResX: %d\n", assPlayResX)
+	fmt.Fprintf(bw, "PlayResY: %d\n", assPlayResY)
+
+	fmt.Fprintf(bw, "\n[V4+ Styles]\n")
+	fmt.Fprintf(bw, "Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, "+
+		"Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, "+
+		"Alignment, MarginL, MarginR, MarginV, Encoding\n")
+
+	alignment, marginH, marginV := 2, 60, 50
+
+	for i, speaker := range speakers {
+		base := ASSStyle{
+			FontName:       "Arial",
+			FontSize:       64,
+			PrimaryColor:   assSpeakerColors[i%len(assSpeakerColors)],
+			SecondaryColor: color.RGBA{R: 0xff, G: 0xff, A: 0xff},
+			OutlineColor:   color.Black,
+			BackColor:      color.NRGBA{A: 0x7f},
+			Alignment:      &alignment,
+			MarginL:        &marginH,
+			MarginR:        &marginH,
+			MarginV:        &marginV,
+		}
+		style := base.merge(opts.DefaultStyle)
+		if s, ok := opts.SpeakerStyles[speaker]; ok {
+			style = style.merge(&s)
+		}
+
+		fmt.Fprintf(bw, "Style: %s,%s,%d,%s,%s,%s,%s,%d,%d,0,0,100,100,0,0,1,3,1,%d,%d,%d,%d,1\n",
+			styleNames[speaker], assEscapeName(style.FontName), style.FontSize,
+			assColor(style.PrimaryColor), assColor(style.SecondaryColor),
+			assColor(style.OutlineColor), assColor(style.BackColor),
+			assBool(style.Bold != nil && *style.Bold), assBool(style.Italic != nil && *style.Italic),
+			*style.Alignment, *style.MarginL, *style.MarginR, *style.MarginV)
+	}
+
+	fmt.Fprintf(bw, "\n[Events]\n")
+	fmt.Fprintf(bw, "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+
+	for _, seg := range segments {
+		var text string
+		if opts.Karaoke {
+			text = assKaraokeText(seg)
+		} else {
+			lines := make([]string, len(seg.cue.Lines))
+			for i, line := range seg.cue.Lines {
+				lines[i] = assEscapeText(line)
+			}
+			text = strings.Join(lines, `\N`)
+		}
+
+		fmt.Fprintf(bw, "Dialogue: 0,%s,%s,%s,%s,0,0,0,,%s\n",
+			assTimestamp(seg.cue.Start), assTimestamp(seg.cue.End),
+			styleNames[seg.speaker], assEscapeName(opts.speakerName(seg.speaker)), text)
+	}
+
+	return bw.Flush()
+}
+
+func (o *ASSOptions) speakerName(speaker int) string {
+	if name, ok := o.SpeakerNames[speaker]; ok && name != "" {
+		return name
+	}
+	return "Speaker " + strconv.Itoa(speaker)
+}
+
+// assKaraokeText returns the event text of seg with a \k tag before each word
+// lasting until the next word starts, in centiseconds.
+func assKaraokeText(seg captionSegment) string {
+	offset := func(d time.Duration) int {
+		if d < seg.cue.Start {
+			d = seg.cue.Start
+		}
+		return int((d - seg.cue.Start + 5*time.Millisecond) / (10 * time.Millisecond))
+	}
+
+	lines := seg.lines()
+
+	var words []captionWord
+	for _, line := range lines {
+		words = append(words, line...)
+	}
+
+	var b strings.Builder
+
+	// words without their own timing start when the word before them ends.
+	starts := make([]int, len(words)+1)
+	prevEnd := seg.cue.Start
+	for i, w := range words {
+		start := w.start
+		if !w.timed {
+			start = prevEnd
+		}
+		starts[i] = offset(start)
+		if i > 0 && starts[i] < starts[i-1] {
+			starts[i] = starts[i-1]
+		}
+		prevEnd = w.end
+	}
+	starts[len(words)] = offset(seg.cue.End)
+	if starts[len(words)] < starts[len(words)-1] {
+		starts[len(words)] = starts[len(words)-1]
+	}
+
+	if starts[0] > 0 {
+		fmt.Fprintf(&b, `{\k%d}`, starts[0])
+	}
+
+	i := 0
+	for n, line := range lines {
+		if n > 0 {
+			b.WriteString(`\N`)
+		}
+		for j, w := range line {
+			fmt.Fprintf(&b, `{\k%d}%s`, starts[i+1]-starts[i], assEscapeText(w.text))
+			if j < len(line)-1 {
+				b.WriteString(" ")
+			}
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// assTimestamp formats d as H:MM:SS.cc.
+func assTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := (d + 5*time.Millisecond) / (10 * time.Millisecond)
+
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// assEscapeText escapes text so override blocks and line break codes are not
+// interpreted by renderers. Line break codes are split with a word joiner.
+func assEscapeText(s string) string {
+	s = strings.NewReplacer(`\n`, "\\\u2060n", `\N`, "\\\u2060N", `\h`, "\\\u2060h").Replace(s)
+	return strings.NewReplacer("{", `\{`, "}", `\}`, "\n", `\N`).Replace(s)
+}
+
+// assEscapeField removes characters that would break a header line.
+func assEscapeField(s string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ").Replace(s)
+}
+
+// assEscapeName escapes a style or Name field, which can not contain commas
+// as they separate the fields of a line.
+func assEscapeName(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(assEscapeField(s), ",", ";"))
+}
+
+// assColor formats c as &HAABBGGRR where an alpha of 00 is opaque.
+func assColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("&H%02X%02X%02X%02X", 0xff-n.A, n.B, n.G, n.R)
+}
+
+// assBool formats b as -1 for true and 0 for false.
+func assBool(b bool) int {
+	if b {
+		return -1
+	}
+	return 0
+}
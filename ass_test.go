@@ -0,0 +1,82 @@
+package revai
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeASS(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeASS(buf, testTranscript, &ASSOptions{Title: "Test"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "[Script Info]\nTitle: Test\nScriptType: v4.00+\n"))
+	assert.Contains(t, out, "\n[V4+ Styles]\n")
+	assert.Contains(t, out, "\n[Events]\n")
+	assert.Contains(t, out, ",Speaker 0,Speaker 0,0,0,0,,Hello, my name is Jane.\n")
+}
+
+func TestEncodeASS_SpeakerStyles(t *testing.T) {
+	on, off := true, false
+	none, margin := 0, 90
+
+	buf := new(bytes.Buffer)
+	err := EncodeASS(buf, testTranscript, &ASSOptions{
+		SpeakerNames: map[int]string{0: "Jane, Host", 1: "Bob"},
+		// a full width default style.
+		DefaultStyle: &ASSStyle{FontName: "Helvetica", Bold: &on, MarginL: &none, MarginR: &none},
+		// speaker styles can turn off the bold of the default style.
+		SpeakerStyles: map[int]ASSStyle{1: {FontSize: 48, Bold: &off, PrimaryColor: color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}, MarginV: &margin}},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.Contains(t, out, "Style: Jane; Host,Helvetica,64,&H00FFFFFF,&H0000FFFF,&H00000000,&H80000000,-1,0,0,0,100,100,0,0,1,3,1,2,0,0,50,1\n")
+	assert.Contains(t, out, "Style: Bob,Helvetica,48,&H00332211,&H0000FFFF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,0,0,90,1\n")
+	assert.Contains(t, out, ",Jane; Host,Jane; Host,0,0,0,,Hello, my name is Jane.\n")
+	assert.Contains(t, out, ",Bob,Bob,0,0,0,,Nice to meet you.\n")
+}
+
+func TestEncodeASS_Karaoke(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeASS(buf, testTranscript, &ASSOptions{Karaoke: true}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Contains(t, buf.String(), `,,{\k50}Hello, {\k20}my {\k30}name {\k20}is {\k72}Jane.`+"\n")
+}
+
+func TestEncodeASS_Timecode(t *testing.T) {
+	start, _ := ParseTimecode("01:00:00:00", FrameRate25)
+
+	buf := new(bytes.Buffer)
+	if err := EncodeASS(buf, testTranscript, &ASSOptions{FrameRate: FrameRate25, StartTimecode: start}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Contains(t, buf.String(), "Dialogue: 0,1:00:00.52,1:00:02.40,Speaker 0,")
+}
+
+func TestASSTimestamp(t *testing.T) {
+	assert.Equal(t, "0:00:00.00", assTimestamp(0))
+	assert.Equal(t, "0:00:01.23", assTimestamp(1234*time.Millisecond))
+	assert.Equal(t, "1:02:03.46", assTimestamp(time.Hour+2*time.Minute+3456*time.Millisecond))
+}
+
+func TestASSEscapeText(t *testing.T) {
+	assert.Equal(t, `a \{b\} c`, assEscapeText("a {b} c"))
+	assert.NotContains(t, assEscapeText(`C:\new`), `\n`)
+}
@@ -3,9 +3,11 @@ package revai
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // CaptionService provides access to the caption related functions
@@ -17,44 +19,95 @@ type Caption struct {
 	Value string
 }
 
+// Decode parses the caption value as a WebVTT or SubRip caption file.
+func (c *Caption) Decode() (*CaptionFile, error) {
+	firstLine := strings.SplitN(strings.TrimPrefix(c.Value, "\ufeff"), "\n", 2)[0]
+	if isVTTHeader(strings.TrimRight(firstLine, "\r")) {
+		return DecodeVTT(strings.NewReader(c.Value))
+	}
+
+	return DecodeSRT(strings.NewReader(c.Value))
+}
+
 // GetCaptionParams specifies the parameters to the
 // CaptionService.Get method.
 type GetCaptionParams struct {
-	JobID  string
+	JobID string
+
+	// Accept is the caption format, either XSubripHeader or TextVTTHeader.
+	// It defaults to XSubripHeader.
 	Accept string
+
+	// MaxCharsPerLine is the maximum number of characters per caption line.
+	MaxCharsPerLine int
+
+	// SpeakerChannel is the channel to caption for jobs submitted with a speaker
+	// channels count. It must be left nil for other jobs.
+	SpeakerChannel *int
+}
+
+type getCaptionParams struct {
+	MaxCharsPerLine int  `url:"max_chars_per_line,omitempty"`
+	SpeakerChannel  *int `url:"speaker_channel,omitempty"`
 }
 
 // Get returns the caption output for a transcription job.
 // https://www.rev.ai/docs#tag/Captions
 func (s *CaptionService) Get(ctx context.Context, params *GetCaptionParams) (*Caption, error) {
-	urlPath := "/speechtotext/v1/jobs/" + params.JobID + "/captions"
+	body, err := s.GetReader(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, body); err != nil {
+		return nil, err
+	}
+
+	caption := Caption{
+		Value: buf.String(),
+	}
+
+	return &caption, nil
+}
+
+// GetReader returns the caption output for a transcription job as a stream,
+// so large captions do not have to be held in memory.
+// The caller must close the returned reader.
+// https://www.rev.ai/docs#tag/Captions
+func (s *CaptionService) GetReader(ctx context.Context, params *GetCaptionParams) (io.ReadCloser, error) {
+	if params.JobID == "" {
+		return nil, errors.New("job id is required")
+	}
 
 	accept := params.Accept
-	if accept != TextVTTHeader {
+	switch accept {
+	case "":
 		accept = XSubripHeader
+	case XSubripHeader, TextVTTHeader:
+	default:
+		return nil, fmt.Errorf("unsupported caption format %q", accept)
+	}
+
+	urlPath := "/speechtotext/v1/jobs/" + params.JobID + "/captions"
+
+	p := &getCaptionParams{
+		MaxCharsPerLine: params.MaxCharsPerLine,
+		SpeakerChannel:  params.SpeakerChannel,
 	}
 
-	req, err := s.client.newRequest(http.MethodGet, urlPath, nil)
+	req, err := s.client.newRequest(http.MethodGet, urlPath, p)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating request %w", err)
 	}
 
-	req.Header.Add("Accept", accept)
+	req.Header.Set("Accept", accept)
 
 	resp, err := s.client.do(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	buf := new(bytes.Buffer)
-	if _, err := io.Copy(buf, resp.Body); err != nil {
-		return nil, err
-	}
 
-	caption := Caption{
-		Value: buf.String(),
-	}
-
-	return &caption, nil
+	return resp.Body, nil
 }
@@ -22,3 +22,63 @@ func TestCaptionService_Get(t *testing.T) {
 
 	assert.NotNil(t, caption.Value, "caption value should not be nil")
 }
+
+func TestCaptionService_GetVTT(t *testing.T) {
+	params := &GetCaptionParams{
+		JobID:           testJob.ID,
+		Accept:          TextVTTHeader,
+		MaxCharsPerLine: 32,
+	}
+
+	ctx := context.Background()
+
+	caption, err := testClient.Caption.Get(ctx, params)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	f, err := caption.Decode()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, CaptionFormatVTT, f.Format)
+}
+
+func TestCaptionService_GetReader(t *testing.T) {
+	params := &GetCaptionParams{
+		JobID: testJob.ID,
+	}
+
+	ctx := context.Background()
+
+	body, err := testClient.Caption.GetReader(ctx, params)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer body.Close()
+
+	f, err := DecodeSRT(body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.NotNil(t, f.Cues, "caption cues should not be nil")
+}
+
+func TestCaptionService_GetUnsupportedFormat(t *testing.T) {
+	params := &GetCaptionParams{
+		JobID:  "job-id",
+		Accept: "application/json",
+	}
+
+	ctx := context.Background()
+
+	_, err := testClient.Caption.Get(ctx, params)
+
+	assert.Error(t, err)
+}
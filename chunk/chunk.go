@@ -0,0 +1,293 @@
+// Package chunk transcribes long media with Rev.ai by splitting it into
+// overlapping chunks at pauses, submitting the chunks as jobs in parallel and
+// stitching their transcripts back together.
+package chunk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	revai "github.com/threeaccents/revai-go"
+	"github.com/threeaccents/revai-go/wav"
+)
+
+// Options specifies how media is chunked and transcribed.
+type Options struct {
+	// ChunkDuration is the longest a chunk may be, overlap included. It
+	// defaults to 20 minutes.
+	ChunkDuration time.Duration
+
+	// Overlap is the audio shared by adjacent chunks, so words cut by a chunk
+	// boundary are transcribed whole by one of them. It defaults to 30
+	// seconds.
+	Overlap time.Duration
+
+	// SilenceSearch is how far before the longest chunk a pause is looked for
+	// to cut at, and SilenceWindow the length of the pause. They default to 30
+	// seconds and half a second.
+	SilenceSearch time.Duration
+	SilenceWindow time.Duration
+
+	// Parallelism is the number of chunks transcribed at once. It defaults
+	// to 4.
+	Parallelism int
+
+	// PollInterval is how often the status of a job is checked. It defaults
+	// to 30 seconds.
+	PollInterval time.Duration
+
+	// Retries is the number of times a chunk is resubmitted after its job
+	// fails. It defaults to 2, a negative value disables retries.
+	Retries int
+
+	// Filename is the name chunks are submitted with, followed by their index.
+	// It defaults to "chunk".
+	Filename string
+
+	// JobOptions are the options of each job.
+	JobOptions *revai.JobOptions
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{
+		ChunkDuration: 20 * time.Minute,
+		Overlap:       30 * time.Second,
+		SilenceSearch: 30 * time.Second,
+		SilenceWindow: 500 * time.Millisecond,
+		Parallelism:   4,
+		PollInterval:  30 * time.Second,
+		Retries:       2,
+		Filename:      "chunk",
+	}
+
+	if o == nil {
+		return opts
+	}
+
+	if o.ChunkDuration > 0 {
+		opts.ChunkDuration = o.ChunkDuration
+	}
+	if o.Overlap > 0 {
+		opts.Overlap = o.Overlap
+	}
+	if o.SilenceSearch > 0 {
+		opts.SilenceSearch = o.SilenceSearch
+	}
+	if o.SilenceWindow > 0 {
+		opts.SilenceWindow = o.SilenceWindow
+	}
+	if o.Parallelism > 0 {
+		opts.Parallelism = o.Parallelism
+	}
+	if o.PollInterval > 0 {
+		opts.PollInterval = o.PollInterval
+	}
+	if o.Retries < 0 {
+		opts.Retries = 0
+	} else if o.Retries > 0 {
+		opts.Retries = o.Retries
+	}
+	if o.Filename != "" {
+		opts.Filename = o.Filename
+	}
+	opts.JobOptions = o.JobOptions
+
+	return opts
+}
+
+// Chunk is a chunk of media from Start to End. Its audio shares the samples
+// of the audio it was split from.
+type Chunk struct {
+	Index int
+	Start time.Duration
+	End   time.Duration
+	Audio *wav.Audio
+}
+
+// Split splits audio into chunks no longer than the chunk duration. Chunks are
+// cut at the quietest point before their longest end and overlap the chunks
+// next to them by half the overlap on each side of the cut.
+func Split(a *wav.Audio, opts *Options) ([]Chunk, error) {
+	o := opts.withDefaults()
+	if o.Overlap >= o.ChunkDuration {
+		return nil, errors.New("overlap must be shorter than the chunk duration")
+	}
+
+	// the search for a pause is limited so every chunk moves forward by at
+	// least half its length.
+	step := o.ChunkDuration - o.Overlap
+	search := o.SilenceSearch
+	if search > step/2 {
+		search = step / 2
+	}
+
+	duration := a.Duration()
+
+	cuts := []time.Duration{0}
+	for {
+		last := cuts[len(cuts)-1]
+		if last+step+o.Overlap/2 >= duration {
+			break
+		}
+		target := last + step
+		cuts = append(cuts, a.Quietest(revai.TimeRange{Start: target - search, End: target}, o.SilenceWindow))
+	}
+	cuts = append(cuts, duration)
+
+	chunks := make([]Chunk, len(cuts)-1)
+	for i := range chunks {
+		start, end := cuts[i], cuts[i+1]
+		if i > 0 {
+			start -= o.Overlap / 2
+		}
+		if i < len(chunks)-1 {
+			end += o.Overlap / 2
+		}
+		chunks[i] = Chunk{
+			Index: i,
+			Start: start,
+			End:   end,
+			Audio: a.Slice(revai.TimeRange{Start: start, End: end}),
+		}
+	}
+
+	return chunks, nil
+}
+
+// Result is the transcript of chunked media.
+type Result struct {
+	Transcript *revai.Transcript
+
+	// Chunks are the chunks of the media and Jobs the jobs which transcribed
+	// them.
+	Chunks []Chunk
+	Jobs   []*revai.Job
+}
+
+// Transcribe splits audio into chunks, submits them to Rev.ai in parallel,
+// waits for their jobs to complete and returns their transcripts stitched
+// together. Chunks whose jobs fail are resubmitted, and the first error
+// stops the remaining chunks.
+func Transcribe(ctx context.Context, c *revai.Client, a *wav.Audio, opts *Options) (*Result, error) {
+	o := opts.withDefaults()
+
+	chunks, err := Split(a, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg          sync.WaitGroup
+		sem         = make(chan struct{}, o.Parallelism)
+		jobs        = make([]*revai.Job, len(chunks))
+		transcripts = make([]*revai.Transcript, len(chunks))
+		errs        = make([]error, len(chunks))
+	)
+	for i := range chunks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			jobs[i], transcripts[i], errs[i] = transcribeChunk(ctx, c, chunks[i], o)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// errors caused by cancelling the other chunks are not returned.
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parts := make([]revai.StitchPart, len(chunks))
+	for i, chunk := range chunks {
+		parts[i] = revai.StitchPart{Transcript: transcripts[i], Offset: chunk.Start, Duration: chunk.End - chunk.Start}
+	}
+
+	return &Result{Transcript: revai.Stitch(parts), Chunks: chunks, Jobs: jobs}, nil
+}
+
+func transcribeChunk(ctx context.Context, c *revai.Client, chunk Chunk, o Options) (*revai.Job, *revai.Transcript, error) {
+	for attempt := 0; ; attempt++ {
+		media := encode(chunk.Audio)
+		job, err := c.Job.SubmitFile(ctx, &revai.NewFileJobParams{
+			Media:      media,
+			Filename:   fmt.Sprintf("%s-%03d.wav", o.Filename, chunk.Index),
+			JobOptions: o.JobOptions,
+		})
+		media.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed submitting chunk %d %w", chunk.Index, err)
+		}
+
+		job, err = wait(ctx, c, job, o.PollInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed getting job of chunk %d %w", chunk.Index, err)
+		}
+
+		if job.Status == "transcribed" {
+			transcript, err := c.Transcript.Get(ctx, &revai.GetTranscriptParams{JobID: job.ID})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed getting transcript of chunk %d %w", chunk.Index, err)
+			}
+			return job, transcript, nil
+		}
+
+		if attempt >= o.Retries {
+			return job, nil, fmt.Errorf("failed transcribing chunk %d, job %s failed: %s", chunk.Index, job.ID, job.Failure)
+		}
+	}
+}
+
+// encode returns the audio encoded as a WAV file as it is read, so chunks are
+// submitted without holding a copy of their encoding. Closing the reader
+// stops the encoding.
+func encode(a *wav.Audio) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(a.Encode(pw))
+	}()
+	return pr
+}
+
+// wait polls a job until it is no longer in progress.
+func wait(ctx context.Context, c *revai.Client, job *revai.Job, interval time.Duration) (*revai.Job, error) {
+	for job.Status == "in_progress" {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var err error
+		job, err = c.Job.Get(ctx, &revai.GetJobParams{ID: job.ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
@@ -0,0 +1,174 @@
+package chunk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	revai "github.com/threeaccents/revai-go"
+	"github.com/threeaccents/revai-go/wav"
+)
+
+// speech returns audio of a tone with tenth of a second pauses at 7 and 14
+// seconds.
+func speech(t *testing.T, d time.Duration) *wav.Audio {
+	silent, err := wav.New(wav.Format{SampleRate: 8000, Channels: 1, BitsPerSample: 16}, d)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return silent.Redact([]revai.TimeRange{
+		{Start: 0, End: 7 * time.Second},
+		{Start: 7100 * time.Millisecond, End: 14 * time.Second},
+		{Start: 14100 * time.Millisecond, End: d},
+	}, &wav.RedactOptions{Fill: wav.Tone, Ramp: time.Millisecond})
+}
+
+// words returns a transcript of speaker 0 saying a word every 0.3 seconds
+// until d.
+func words(d time.Duration) *revai.Transcript {
+	monologue := revai.Monologue{}
+	for i := 0; time.Duration(i)*300*time.Millisecond < d; i++ {
+		if i > 0 {
+			monologue.Elements = append(monologue.Elements, revai.Element{Type: "punct", Value: " "})
+		}
+		ts := float64(i*300) / 1000
+		monologue.Elements = append(monologue.Elements, revai.Element{Type: "text", Value: fmt.Sprintf("w%d", i), Ts: ts, EndTs: float64(i*300+300) / 1000, Confidence: 1})
+	}
+	return &revai.Transcript{Monologues: []revai.Monologue{monologue}}
+}
+
+func TestSplit(t *testing.T) {
+	a := speech(t, 22*time.Second)
+
+	chunks, err := Split(a, &Options{ChunkDuration: 10 * time.Second, Overlap: 2 * time.Second, SilenceSearch: 3 * time.Second, SilenceWindow: 100 * time.Millisecond})
+	assert.NoError(t, err)
+
+	// cuts are made in the pauses, with a second of overlap on each side.
+	if assert.Len(t, chunks, 3) {
+		assert.Equal(t, time.Duration(0), chunks[0].Start)
+		assert.Equal(t, 8050*time.Millisecond, chunks[0].End)
+		assert.Equal(t, 6050*time.Millisecond, chunks[1].Start)
+		assert.Equal(t, 15050*time.Millisecond, chunks[1].End)
+		assert.Equal(t, 13050*time.Millisecond, chunks[2].Start)
+		assert.Equal(t, 22*time.Second, chunks[2].End)
+	}
+	for i, chunk := range chunks {
+		assert.Equal(t, i, chunk.Index)
+		assert.True(t, chunk.End-chunk.Start <= 10*time.Second)
+		assert.Equal(t, chunk.End-chunk.Start, chunk.Audio.Duration())
+	}
+
+	// chunks share the samples of the audio rather than copying them.
+	if len(chunks) > 1 {
+		chunks[1].Audio.SetSample(0, 0, 0.5)
+		assert.Equal(t, chunks[1].Audio.Sample(0, 0), a.Sample(a.Frames()*6050/22000, 0))
+	}
+
+	short, err := Split(a, &Options{ChunkDuration: time.Minute})
+	assert.NoError(t, err)
+	assert.Len(t, short, 1)
+
+	_, err = Split(a, &Options{ChunkDuration: time.Second, Overlap: time.Second})
+	assert.Error(t, err)
+}
+
+func TestTranscribe(t *testing.T) {
+	a := speech(t, 22*time.Second)
+	full := words(22 * time.Second)
+	opts := &Options{
+		ChunkDuration: 10 * time.Second,
+		Overlap:       2 * time.Second,
+		SilenceSearch: 3 * time.Second,
+		SilenceWindow: 100 * time.Millisecond,
+		PollInterval:  time.Millisecond,
+		Filename:      "talk",
+	}
+
+	chunks, err := Split(a, opts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var (
+		mu       sync.Mutex
+		attempts = map[int]int{}
+		polls    = map[string]int{}
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/speechtotext/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("media")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var index int
+		fmt.Sscanf(header.Filename, "talk-%03d.wav", &index)
+
+		mu.Lock()
+		attempts[index]++
+		id := fmt.Sprintf("%d-%d", index, attempts[index])
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(revai.Job{ID: id, Status: "in_progress"})
+	})
+	mux.HandleFunc("/speechtotext/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/speechtotext/v1/jobs/")
+
+		var index, attempt int
+		fmt.Sscanf(path, "%d-%d", &index, &attempt)
+
+		if strings.HasSuffix(path, "/transcript") {
+			chunk := chunks[index]
+			json.NewEncoder(w).Encode(full.Slice(chunk.Start, chunk.End, nil).Shift(-chunk.Start))
+			return
+		}
+
+		mu.Lock()
+		polls[path]++
+		n := polls[path]
+		mu.Unlock()
+
+		// the first job of the second chunk fails.
+		status := "transcribed"
+		switch {
+		case n < 2:
+			status = "in_progress"
+		case index == 1 && attempt == 1:
+			status = "failed"
+		}
+		json.NewEncoder(w).Encode(revai.Job{ID: path, Status: status})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	c := revai.NewClient("key", revai.BaseURL(u))
+
+	result, err := Transcribe(context.Background(), c, a, opts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, full, result.Transcript)
+	assert.Len(t, result.Chunks, 3)
+	if assert.Len(t, result.Jobs, 3) {
+		assert.Equal(t, "1-2", result.Jobs[1].ID)
+	}
+
+	// chunks failing every attempt fail the transcription.
+	opts.Retries = -1
+	attempts = map[int]int{}
+	_, err = Transcribe(context.Background(), c, a, opts)
+	assert.Error(t, err)
+}
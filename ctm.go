@@ -0,0 +1,153 @@
+package revai
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CTMOptions specifies the waveform fields written by EncodeCTM.
+type CTMOptions struct {
+	// File is the waveform file name. It defaults to "rev".
+	File string
+
+	// Channel is the waveform channel. It defaults to "1".
+	Channel string
+}
+
+func (o *CTMOptions) withDefaults() CTMOptions {
+	opts := CTMOptions{File: "rev", Channel: "1"}
+
+	if o == nil {
+		return opts
+	}
+
+	if o.File != "" {
+		opts.File = o.File
+	}
+	if o.Channel != "" {
+		opts.Channel = o.Channel
+	}
+
+	return opts
+}
+
+// EncodeCTM writes the text elements of the transcript to w as a NIST time
+// marked conversation file with a line per word holding its start time,
+// duration and confidence. Punctuation is left out.
+func EncodeCTM(w io.Writer, t *Transcript, opts *CTMOptions) error {
+	o := opts.withDefaults()
+
+	var words []Element
+	for _, monologue := range t.Monologues {
+		for _, element := range monologue.Elements {
+			if element.Type != ElementText {
+				continue
+			}
+			for _, a := range splitAnnotation(annotation{start: element.Ts, end: element.EndTs, text: element.Value}) {
+				words = append(words, Element{Value: a.text, Ts: a.start, EndTs: a.end, Confidence: element.Confidence})
+			}
+		}
+	}
+
+	sort.SliceStable(words, func(i, j int) bool { return words[i].Ts < words[j].Ts })
+
+	bw := bufio.NewWriter(w)
+	for _, word := range words {
+		fmt.Fprintf(bw, "%s %s %.3f %.3f %s %.2f\n",
+			evaluationField(o.File), evaluationField(o.Channel), word.Ts, word.EndTs-word.Ts, word.Value, word.Confidence)
+	}
+
+	return bw.Flush()
+}
+
+// DecodeCTM reads a NIST time marked conversation file and returns a
+// transcript for each waveform file in it. Each channel is read as a speaker,
+// numbered in order of first appearance, and consecutive words of a channel
+// make a monologue. Words without a confidence have a confidence of 1.
+func DecodeCTM(r io.Reader) (map[string]*Transcript, error) {
+	type word struct {
+		channel int
+		element Element
+	}
+
+	var (
+		files    = map[string][]word{}
+		channels = map[string]int{}
+	)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";;") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("invalid ctm line %d", n)
+		}
+
+		begin, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ctm begin time on line %d", n)
+		}
+		duration, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ctm duration on line %d", n)
+		}
+
+		confidence := 1.0
+		if len(fields) > 5 && fields[5] != "NA" {
+			if confidence, err = strconv.ParseFloat(fields[5], 64); err != nil {
+				return nil, fmt.Errorf("invalid ctm confidence on line %d", n)
+			}
+		}
+
+		channel, ok := channels[fields[1]]
+		if !ok {
+			channel = len(channels)
+			channels[fields[1]] = channel
+		}
+
+		files[fields[0]] = append(files[fields[0]], word{channel, Element{
+			Type:       ElementText,
+			Value:      fields[4],
+			Ts:         begin,
+			EndTs:      begin + duration,
+			Confidence: confidence,
+		}})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading ctm %w", err)
+	}
+
+	transcripts := map[string]*Transcript{}
+	for file, words := range files {
+		sort.SliceStable(words, func(i, j int) bool { return words[i].element.Ts < words[j].element.Ts })
+
+		t := &Transcript{}
+		for i, w := range words {
+			if i == 0 || words[i-1].channel != w.channel {
+				t.Monologues = append(t.Monologues, Monologue{Speaker: w.channel})
+			}
+			m := &t.Monologues[len(t.Monologues)-1]
+			if len(m.Elements) > 0 {
+				m.Elements = append(m.Elements, Element{Type: ElementPunct, Value: " "})
+			}
+			m.Elements = append(m.Elements, w.element)
+		}
+		transcripts[file] = t
+	}
+
+	return transcripts, nil
+}
+
+// evaluationField replaces whitespace which would split a CTM or STM field.
+func evaluationField(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
@@ -0,0 +1,86 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeCTM(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeCTM(buf, testTranscript, &CTMOptions{File: "interview 1"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 9)
+	assert.Equal(t, "interview_1 1 0.500 0.400 Hello 1.00", lines[0])
+	assert.Equal(t, "interview_1 1 3.100 0.300 you 0.30", lines[8])
+
+	transcripts, err := DecodeCTM(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	decoded := transcripts["interview_1"]
+	if !assert.NotNil(t, decoded) || !assert.Len(t, decoded.Monologues, 1) {
+		return
+	}
+	elements := decoded.Monologues[0].Elements
+	assert.Len(t, elements, 17)
+	assert.Equal(t, Element{Type: "text", Value: "meet", Ts: 2.9, EndTs: 3.1, Confidence: 0.4}, elements[14])
+}
+
+func TestDecodeCTM(t *testing.T) {
+	const ctm = `;; comment
+a A 0.10 0.20 hi
+b A 0.00 0.50 yes 0.75
+a B 0.40 0.20 there NA
+a A 0.70 0.20 you
+`
+
+	transcripts, err := DecodeCTM(strings.NewReader(ctm))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Len(t, transcripts, 2)
+	monologues := transcripts["a"].Monologues
+	if assert.Len(t, monologues, 3) {
+		assert.Equal(t, []int{0, 1, 0}, []int{monologues[0].Speaker, monologues[1].Speaker, monologues[2].Speaker})
+		there := monologues[1].Elements[0]
+		assert.Equal(t, "there", there.Value)
+		assert.Equal(t, 1.0, there.Confidence)
+		assert.InDelta(t, 0.6, there.EndTs, 1e-9)
+	}
+	assert.Equal(t, 0.75, transcripts["b"].Monologues[0].Elements[0].Confidence)
+
+	_, err = DecodeCTM(strings.NewReader("a A 0.1 hi\n"))
+	assert.Error(t, err)
+}
+
+func TestEncodeCTM_EmptyWords(t *testing.T) {
+	transcript := &Transcript{Monologues: []Monologue{{Elements: []Element{
+		{Type: ElementText, Value: "hi", Ts: 0, EndTs: 0.5, Confidence: 1},
+		{Type: ElementText, Value: "", Ts: 0.5, EndTs: 0.6},
+		{Type: ElementText, Value: "  ", Ts: 0.6, EndTs: 0.7},
+	}}}}
+
+	// elements without words are left out rather than panicking.
+	buf := new(bytes.Buffer)
+	assert.NoError(t, EncodeCTM(buf, transcript, nil))
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	assert.NoError(t, EncodeTextGrid(buf, transcript, nil))
+	assert.NotContains(t, buf.String(), `text = "  "`)
+
+	buf.Reset()
+	assert.NoError(t, EncodeEAF(buf, transcript, nil))
+	assert.Equal(t, 1, strings.Count(buf.String(), "<ANNOTATION_VALUE>hi</ANNOTATION_VALUE>"))
+}
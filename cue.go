@@ -0,0 +1,446 @@
+package revai
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCueMaxCharsPerLine = 32
+	defaultCueMaxLines        = 2
+	defaultCueMaxDuration     = 6 * time.Second
+)
+
+// CaptionFormat is the text format of a caption file.
+type CaptionFormat string
+
+const (
+	CaptionFormatSRT CaptionFormat = "srt"
+	CaptionFormatVTT CaptionFormat = "vtt"
+)
+
+// Cue represents a single timed caption. Lines hold the cue text as written
+// in the caption file, including any markup such as WebVTT voice tags.
+type Cue struct {
+	ID       string
+	Start    time.Duration
+	End      time.Duration
+	Settings []CueSetting
+	Lines    []string
+}
+
+// CueSetting is a WebVTT cue setting such as line:90% or align:start.
+type CueSetting struct {
+	Name  string
+	Value string
+}
+
+// Text returns the cue lines joined by a newline.
+func (c Cue) Text() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+// PlainText returns the cue text with markup tags removed and
+// character references decoded.
+func (c Cue) PlainText() string {
+	return strings.Join(c.PlainLines(), "\n")
+}
+
+// PlainLines returns the cue lines with markup tags removed and
+// character references decoded.
+func (c Cue) PlainLines() []string {
+	lines := make([]string, len(c.Lines))
+	for i, line := range c.Lines {
+		lines[i] = unescapeCueText(filterCueTags(line, func(string) bool { return false }))
+	}
+	return lines
+}
+
+// Voice returns the annotation of the first WebVTT voice tag in the cue,
+// for example "Esme" for <v.loud Esme>.
+func (c Cue) Voice() string {
+	for _, line := range c.Lines {
+		for _, tag := range cueTags(line) {
+			if cueTagName(tag) != "v" {
+				continue
+			}
+			if i := strings.IndexAny(tag, " \t"); i >= 0 {
+				return strings.TrimSpace(tag[i+1:])
+			}
+		}
+	}
+	return ""
+}
+
+// Setting returns the value of the named cue setting.
+func (c Cue) Setting(name string) (string, bool) {
+	for _, s := range c.Settings {
+		if s.Name == name {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+// Duration returns how long the cue is displayed for.
+func (c Cue) Duration() time.Duration {
+	return c.End - c.Start
+}
+
+// CueOptions specifies how transcript elements are grouped
+// into cues by the Transcript.Cues method.
+type CueOptions struct {
+	MaxCharsPerLine int
+	MaxLines        int
+	MaxDuration     time.Duration
+
+	// FrameRate snaps cue times to its frames when set, and StartTimecode
+	// is added to them, as with SnapCues.
+	FrameRate     FrameRate
+	StartTimecode Timecode
+}
+
+func (o *CueOptions) withDefaults() CueOptions {
+	opts := CueOptions{
+		MaxCharsPerLine: defaultCueMaxCharsPerLine,
+		MaxLines:        defaultCueMaxLines,
+		MaxDuration:     defaultCueMaxDuration,
+	}
+
+	if o == nil {
+		return opts
+	}
+
+	if o.MaxCharsPerLine > 0 {
+		opts.MaxCharsPerLine = o.MaxCharsPerLine
+	}
+	if o.MaxLines > 0 {
+		opts.MaxLines = o.MaxLines
+	}
+	if o.MaxDuration > 0 {
+		opts.MaxDuration = o.MaxDuration
+	}
+	opts.FrameRate = o.FrameRate
+	opts.StartTimecode = o.StartTimecode
+
+	return opts
+}
+
+// Cues groups the transcript elements into caption cues. A new cue is started
+// whenever the speaker changes, the text no longer fits in the configured lines
+// or the cue would be displayed for longer than the max duration.
+func (t *Transcript) Cues(opts *CueOptions) []Cue {
+	o := opts.withDefaults()
+
+	var cues []Cue
+
+	for _, monologue := range t.Monologues {
+		var (
+			text     string
+			start    float64
+			end      float64
+			hasWords bool
+		)
+
+		flush := func() {
+			if !hasWords {
+				return
+			}
+			cues = append(cues, Cue{
+				Start: secondsToDuration(start),
+				End:   secondsToDuration(end),
+				Lines: wrapText(text, o.MaxCharsPerLine),
+			})
+			text = ""
+			hasWords = false
+		}
+
+		for _, element := range monologue.Elements {
+			if element.Type != ElementText {
+				if hasWords {
+					text += element.Value
+				}
+				continue
+			}
+
+			candidate := strings.TrimSpace(text) + " " + element.Value
+			if !hasWords {
+				candidate = element.Value
+			}
+
+			if hasWords {
+				tooLong := len(wrapText(candidate, o.MaxCharsPerLine)) > o.MaxLines
+				tooSlow := secondsToDuration(element.EndTs-start) > o.MaxDuration
+				if tooLong || tooSlow {
+					flush()
+					candidate = element.Value
+				}
+			}
+
+			if !hasWords {
+				start = element.Ts
+				hasWords = true
+			}
+
+			text = candidate
+			end = element.EndTs
+		}
+
+		flush()
+	}
+
+	if o.FrameRate.IsZero() && o.StartTimecode == (Timecode{}) {
+		return cues
+	}
+	return SnapCues(cues, o.FrameRate, o.StartTimecode)
+}
+
+// wrapText breaks s into lines of at most width characters on word
+// boundaries. Words longer than width are placed on a line of their own.
+func wrapText(s string, width int) []string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
+
+// CaptionBlockType is the type of a non-cue block in a caption file.
+type CaptionBlockType string
+
+const (
+	CaptionBlockNote   CaptionBlockType = "NOTE"
+	CaptionBlockStyle  CaptionBlockType = "STYLE"
+	CaptionBlockRegion CaptionBlockType = "REGION"
+)
+
+// CaptionBlock is a WebVTT NOTE, STYLE or REGION block. It is written before the
+// cue at CueIndex, or after the last cue when CueIndex is the number of cues.
+type CaptionBlock struct {
+	Type     CaptionBlockType
+	CueIndex int
+	Text     string
+}
+
+// CaptionFile is a parsed SRT or WebVTT caption file.
+type CaptionFile struct {
+	Format CaptionFormat
+
+	// Header is the text following WEBVTT in the header of a WebVTT file,
+	// including any header lines.
+	Header string
+
+	Blocks []CaptionBlock
+	Cues   []Cue
+}
+
+// cueTags returns the content of each <...> tag in line.
+func cueTags(line string) []string {
+	var tags []string
+	filterCueTags(line, func(tag string) bool {
+		tags = append(tags, tag)
+		return true
+	})
+	return tags
+}
+
+// filterCueTags removes the tags from line that keep does not return true for.
+// keep is called with the tag content without the angle brackets.
+func filterCueTags(line string, keep func(tag string) bool) string {
+	return mapCueText(line, keep, func(text string) string { return text })
+}
+
+// mapCueText removes the tags from line that keep does not return true for
+// and replaces the text between tags with the result of text.
+func mapCueText(line string, keep func(tag string) bool, text func(string) string) string {
+	var b strings.Builder
+
+	for {
+		start := strings.IndexByte(line, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(line[start:], '>')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(text(line[:start]))
+		if keep(line[start+1 : end]) {
+			b.WriteString(line[start : end+1])
+		}
+		line = line[end+1:]
+	}
+
+	b.WriteString(text(line))
+
+	return b.String()
+}
+
+// cueTagName returns the name of a tag without its classes and annotation.
+// Timestamp tags are named by their timestamp.
+func cueTagName(tag string) string {
+	tag = strings.TrimPrefix(tag, "/")
+	if i := strings.IndexAny(tag, " \t."); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
+
+var cueTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var cueTextUnescaper = strings.NewReplacer(
+	"&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", "\u00a0",
+	"&lrm;", "\u200e", "&rlm;", "\u200f", "&quot;", "\"", "&apos;", "'",
+)
+
+func escapeCueText(s string) string {
+	return cueTextEscaper.Replace(s)
+}
+
+func unescapeCueText(s string) string {
+	return cueTextUnescaper.Replace(s)
+}
+
+// parseCueTimestamp parses a WebVTT or SRT timestamp where the hours are optional
+// and the milliseconds are separated by a period or a comma.
+func parseCueTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	secs := strings.Replace(parts[len(parts)-1], ",", ".", 1)
+	i := strings.IndexByte(secs, '.')
+	if i < 0 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var n [4]int
+	fields := append(parts[:len(parts)-1], secs[:i], secs[i+1:])
+	offset := 4 - len(fields)
+	for j, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil || v < 0 || field == "" {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		n[offset+j] = v
+	}
+
+	if n[1] > 59 || n[2] > 59 || len(secs[i+1:]) != 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	return time.Duration(n[0])*time.Hour + time.Duration(n[1])*time.Minute +
+		time.Duration(n[2])*time.Second + time.Duration(n[3])*time.Millisecond, nil
+}
+
+// formatCueTimestamp formats d as hh:mm:ss followed by sep and milliseconds.
+func formatCueTimestamp(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Round(time.Millisecond) / time.Millisecond
+
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// splitCaptionBlocks splits caption file content into blocks of lines
+// separated by blank lines.
+func splitCaptionBlocks(r io.Reader) ([][]string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var (
+		blocks [][]string
+		block  []string
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+// parseCueTiming parses a "start --> end settings" timing line.
+func parseCueTiming(line string) (Cue, error) {
+	var cue Cue
+
+	i := strings.Index(line, "-->")
+	if i < 0 {
+		return cue, fmt.Errorf("invalid cue timing %q", line)
+	}
+
+	start, err := parseCueTimestamp(line[:i])
+	if err != nil {
+		return cue, err
+	}
+
+	fields := strings.Fields(line[i+3:])
+	if len(fields) == 0 {
+		return cue, fmt.Errorf("invalid cue timing %q", line)
+	}
+
+	end, err := parseCueTimestamp(fields[0])
+	if err != nil {
+		return cue, err
+	}
+
+	cue.Start = start
+	cue.End = end
+
+	for _, field := range fields[1:] {
+		setting := CueSetting{Name: field}
+		if j := strings.IndexByte(field, ':'); j >= 0 {
+			setting = CueSetting{Name: field[:j], Value: field[j+1:]}
+		}
+		cue.Settings = append(cue.Settings, setting)
+	}
+
+	return cue, nil
+}
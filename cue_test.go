@@ -0,0 +1,74 @@
+package revai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTranscript = &Transcript{
+	Monologues: []Monologue{
+		{
+			Speaker: 0,
+			Elements: []Element{
+				{Type: "text", Value: "Hello", Ts: 0.5, EndTs: 0.9, Confidence: 1},
+				{Type: "punct", Value: ","},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "my", Ts: 1.0, EndTs: 1.2, Confidence: 0.9},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "name", Ts: 1.2, EndTs: 1.5, Confidence: 0.8},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "is", Ts: 1.5, EndTs: 1.7, Confidence: 0.95},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "Jane", Ts: 1.7, EndTs: 2.1, Confidence: 0.6},
+				{Type: "punct", Value: "."},
+			},
+		},
+		{
+			Speaker: 1,
+			Elements: []Element{
+				{Type: "text", Value: "Nice", Ts: 2.5, EndTs: 2.8, Confidence: 1},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "to", Ts: 2.8, EndTs: 2.9, Confidence: 1},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "meet", Ts: 2.9, EndTs: 3.1, Confidence: 0.4},
+				{Type: "punct", Value: " "},
+				{Type: "text", Value: "you", Ts: 3.1, EndTs: 3.4, Confidence: 0.3},
+				{Type: "punct", Value: "."},
+			},
+		},
+	},
+}
+
+func TestTranscript_Cues(t *testing.T) {
+	cues := testTranscript.Cues(nil)
+
+	assert.Equal(t, []Cue{
+		{Start: 500 * time.Millisecond, End: 2100 * time.Millisecond, Lines: []string{"Hello, my name is Jane."}},
+		{Start: 2500 * time.Millisecond, End: 3400 * time.Millisecond, Lines: []string{"Nice to meet you."}},
+	}, cues)
+}
+
+func TestTranscript_CuesTimecode(t *testing.T) {
+	start, _ := ParseTimecode("01:00:00:00", FrameRate25)
+	cues := testTranscript.Cues(&CueOptions{FrameRate: FrameRate25, StartTimecode: start})
+
+	if assert.Len(t, cues, 2) {
+		assert.Equal(t, time.Hour+520*time.Millisecond, cues[0].Start)
+		assert.Equal(t, time.Hour+2120*time.Millisecond, cues[0].End)
+	}
+}
+
+func TestTranscript_CuesMaxChars(t *testing.T) {
+	cues := testTranscript.Cues(&CueOptions{MaxCharsPerLine: 10, MaxLines: 1})
+
+	var text []string
+	for _, cue := range cues {
+		assert.Len(t, cue.Lines, 1)
+		assert.LessOrEqual(t, len(cue.Lines[0]), 10)
+		text = append(text, cue.Text())
+	}
+
+	assert.Equal(t, []string{"Hello, my", "name is", "Jane.", "Nice to", "meet you."}, text)
+}
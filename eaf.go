@@ -0,0 +1,291 @@
+package revai
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	eafSpeakerType = "utterance"
+	eafWordType    = "words"
+)
+
+// EAFOptions specifies how EncodeEAF writes an ELAN annotation document.
+// DecodeEAF returns the options read from a file.
+type EAFOptions struct {
+	// SpeakerNames maps Monologue.Speaker to the participant and tier name
+	// of its speaker tier. Speakers without a name are called "Speaker N",
+	// and the tiers of speakers sharing a name are followed by their number.
+	SpeakerNames map[int]string
+
+	// Words adds a "<speaker> words" tier with an annotation per text element
+	// as a child of each speaker tier.
+	Words bool
+
+	// Author is written to the document header.
+	Author string
+
+	// MediaURL is the URL of the transcribed media, for example file:///audio.wav.
+	MediaURL string
+
+	// MediaType is the MIME type of the media. It defaults to audio/x-wav.
+	MediaType string
+}
+
+type eafDocument struct {
+	XMLName         xml.Name            `xml:"ANNOTATION_DOCUMENT"`
+	Author          string              `xml:"AUTHOR,attr"`
+	Date            string              `xml:"DATE,attr"`
+	Format          string              `xml:"FORMAT,attr"`
+	Version         string              `xml:"VERSION,attr"`
+	XSI             string              `xml:"xmlns:xsi,attr,omitempty"`
+	Schema          string              `xml:"xsi:noNamespaceSchemaLocation,attr,omitempty"`
+	Header          eafHeader           `xml:"HEADER"`
+	TimeSlots       []eafTimeSlot       `xml:"TIME_ORDER>TIME_SLOT"`
+	Tiers           []eafTier           `xml:"TIER"`
+	LinguisticTypes []eafLinguisticType `xml:"LINGUISTIC_TYPE"`
+	Constraints     []eafConstraint     `xml:"CONSTRAINT"`
+}
+
+type eafHeader struct {
+	MediaFile string     `xml:"MEDIA_FILE,attr"`
+	TimeUnits string     `xml:"TIME_UNITS,attr"`
+	Media     []eafMedia `xml:"MEDIA_DESCRIPTOR"`
+}
+
+type eafMedia struct {
+	URL      string `xml:"MEDIA_URL,attr"`
+	MIMEType string `xml:"MIME_TYPE,attr"`
+}
+
+type eafTimeSlot struct {
+	ID    string `xml:"TIME_SLOT_ID,attr"`
+	Value *int64 `xml:"TIME_VALUE,attr"`
+}
+
+type eafTier struct {
+	ID             string          `xml:"TIER_ID,attr"`
+	Participant    string          `xml:"PARTICIPANT,attr,omitempty"`
+	LinguisticType string          `xml:"LINGUISTIC_TYPE_REF,attr"`
+	Parent         string          `xml:"PARENT_REF,attr,omitempty"`
+	Annotations    []eafAnnotation `xml:"ANNOTATION>ALIGNABLE_ANNOTATION"`
+}
+
+type eafAnnotation struct {
+	ID    string `xml:"ANNOTATION_ID,attr"`
+	Slot1 string `xml:"TIME_SLOT_REF1,attr"`
+	Slot2 string `xml:"TIME_SLOT_REF2,attr"`
+	Value string `xml:"ANNOTATION_VALUE"`
+}
+
+type eafLinguisticType struct {
+	ID            string `xml:"LINGUISTIC_TYPE_ID,attr"`
+	TimeAlignable bool   `xml:"TIME_ALIGNABLE,attr"`
+	Constraints   string `xml:"CONSTRAINTS,attr,omitempty"`
+}
+
+type eafConstraint struct {
+	Stereotype  string `xml:"STEREOTYPE,attr"`
+	Description string `xml:"DESCRIPTION,attr"`
+}
+
+// EncodeEAF writes the transcript to w as an ELAN annotation document with a
+// tier per speaker holding its monologues. Times are in milliseconds.
+func EncodeEAF(w io.Writer, t *Transcript, opts *EAFOptions) error {
+	if opts == nil {
+		opts = &EAFOptions{}
+	}
+
+	doc := eafDocument{
+		Author:  opts.Author,
+		Date:    time.Now().Format(time.RFC3339),
+		Format:  "3.0",
+		Version: "3.0",
+		XSI:     "http://www.w3.org/2001/XMLSchema-instance",
+		Schema:  "http://www.mpi.nl/tools/elan/EAFv3.0.xsd",
+		Header:  eafHeader{TimeUnits: "milliseconds"},
+		LinguisticTypes: []eafLinguisticType{
+			{ID: eafSpeakerType, TimeAlignable: true},
+		},
+	}
+
+	if opts.MediaURL != "" {
+		mediaType := opts.MediaType
+		if mediaType == "" {
+			mediaType = "audio/x-wav"
+		}
+		doc.Header.Media = []eafMedia{{URL: opts.MediaURL, MIMEType: mediaType}}
+	}
+
+	type tier struct {
+		eafTier
+		annotations []annotation
+	}
+
+	// tier IDs are unique, speakers sharing a name are told apart by their
+	// number.
+	var tiers []tier
+	used := map[string]bool{}
+	for _, sa := range t.speakerAnnotations(opts.SpeakerNames) {
+		id := sa.name
+		for n := 1; used[id] || used[id+textGridWordsSuffix]; n++ {
+			id = sa.name + " " + strconv.Itoa(sa.speaker)
+			if n > 1 {
+				id += "-" + strconv.Itoa(n)
+			}
+		}
+		used[id] = true
+
+		tiers = append(tiers, tier{eafTier{
+			ID:             id,
+			Participant:    sa.name,
+			LinguisticType: eafSpeakerType,
+		}, sa.turns})
+		if opts.Words {
+			used[id+textGridWordsSuffix] = true
+			tiers = append(tiers, tier{eafTier{
+				ID:             id + textGridWordsSuffix,
+				Participant:    sa.name,
+				LinguisticType: eafWordType,
+				Parent:         id,
+			}, sa.words})
+		}
+	}
+
+	if opts.Words {
+		doc.LinguisticTypes = append(doc.LinguisticTypes, eafLinguisticType{
+			ID:            eafWordType,
+			TimeAlignable: true,
+			Constraints:   "Included_In",
+		})
+		doc.Constraints = []eafConstraint{{
+			Stereotype:  "Included_In",
+			Description: "Time alignment of this tier is within the alignment of the parent tier",
+		}}
+	}
+
+	// time slots are numbered in time order and shared by annotations
+	// at the same millisecond.
+	ms := func(seconds float64) int64 { return int64(math.Round(seconds * 1000)) }
+
+	var times []int64
+	slots := map[int64]string{}
+	for _, tier := range tiers {
+		for _, a := range tier.annotations {
+			for _, v := range []int64{ms(a.start), ms(a.end)} {
+				if _, ok := slots[v]; !ok {
+					slots[v] = ""
+					times = append(times, v)
+				}
+			}
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	for i, v := range times {
+		value := v
+		slots[v] = "ts" + strconv.Itoa(i+1)
+		doc.TimeSlots = append(doc.TimeSlots, eafTimeSlot{ID: slots[v], Value: &value})
+	}
+
+	annotationID := 0
+	for _, tier := range tiers {
+		for _, a := range tier.annotations {
+			annotationID++
+			tier.Annotations = append(tier.Annotations, eafAnnotation{
+				ID:    "a" + strconv.Itoa(annotationID),
+				Slot1: slots[ms(a.start)],
+				Slot2: slots[ms(a.end)],
+				Value: a.text,
+			})
+		}
+		doc.Tiers = append(doc.Tiers, tier.eafTier)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "    ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed encoding eaf %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// DecodeEAF reads an ELAN annotation document. Tiers without a parent become
+// speakers, named by their participant or tier ID, and a time aligned child
+// tier gives the timing of the words of its parent. Words of annotations
+// without a child tier are spread evenly over the annotation. Annotations
+// with unaligned time slots are skipped. Speakers named "Speaker N" are read
+// as speaker N, other names are returned in the options.
+func DecodeEAF(r io.Reader) (*Transcript, *EAFOptions, error) {
+	var doc eafDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed decoding eaf %w", err)
+	}
+
+	seconds := map[string]float64{}
+	for _, ts := range doc.TimeSlots {
+		if ts.Value != nil {
+			seconds[ts.ID] = float64(*ts.Value) / 1000
+		}
+	}
+
+	toAnnotations := func(tier eafTier) []annotation {
+		var as []annotation
+		for _, ea := range tier.Annotations {
+			start, ok1 := seconds[ea.Slot1]
+			end, ok2 := seconds[ea.Slot2]
+			if !ok1 || !ok2 {
+				continue
+			}
+			as = append(as, annotation{start: start, end: end, text: ea.Value})
+		}
+		sort.SliceStable(as, func(i, j int) bool { return as[i].start < as[j].start })
+		return as
+	}
+
+	opts := &EAFOptions{Author: doc.Author}
+	if len(doc.Header.Media) > 0 {
+		opts.MediaURL = doc.Header.Media[0].URL
+		opts.MediaType = doc.Header.Media[0].MIMEType
+	}
+
+	var speakers []speakerAnnotations
+	index := map[string]int{}
+	for _, tier := range doc.Tiers {
+		if tier.Parent != "" {
+			continue
+		}
+		name := tier.Participant
+		if name == "" {
+			name = tier.ID
+		}
+		index[tier.ID] = len(speakers)
+		speakers = append(speakers, speakerAnnotations{name: name, turns: toAnnotations(tier)})
+	}
+
+	for _, tier := range doc.Tiers {
+		i, ok := index[tier.Parent]
+		if !ok || speakers[i].words != nil {
+			continue
+		}
+		if words := toAnnotations(tier); len(words) > 0 {
+			speakers[i].words = words
+			opts.Words = true
+		}
+	}
+
+	opts.SpeakerNames = numberSpeakers(speakers)
+
+	return annotationsTranscript(speakers), opts, nil
+}
@@ -0,0 +1,90 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeEAF(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := &EAFOptions{SpeakerNames: map[int]string{0: "Jane"}, Words: true, MediaURL: "file:///interview.wav"}
+	if err := EncodeEAF(buf, testTranscript, opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.Contains(t, out, `<MEDIA_DESCRIPTOR MEDIA_URL="file:///interview.wav" MIME_TYPE="audio/x-wav"></MEDIA_DESCRIPTOR>`)
+	assert.Contains(t, out, `<TIME_SLOT TIME_SLOT_ID="ts1" TIME_VALUE="500"></TIME_SLOT>`)
+	assert.Contains(t, out, `<TIER TIER_ID="Jane words" PARTICIPANT="Jane" LINGUISTIC_TYPE_REF="words" PARENT_REF="Jane">`)
+	assert.Contains(t, out, `<ANNOTATION_VALUE>Hello, my name is Jane.</ANNOTATION_VALUE>`)
+
+	decoded, decodedOpts, err := DecodeEAF(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, withoutConfidence(testTranscript), withoutConfidence(decoded))
+	assert.Equal(t, map[int]string{0: "Jane"}, decodedOpts.SpeakerNames)
+	assert.True(t, decodedOpts.Words)
+	assert.Equal(t, "file:///interview.wav", decodedOpts.MediaURL)
+}
+
+func TestEncodeEAF_DuplicateNames(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := &EAFOptions{SpeakerNames: map[int]string{0: "Guest", 1: "Guest"}, Words: true}
+	if err := EncodeEAF(buf, testTranscript, opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.Contains(t, out, `<TIER TIER_ID="Guest" PARTICIPANT="Guest" LINGUISTIC_TYPE_REF="utterance">`)
+	assert.Contains(t, out, `<TIER TIER_ID="Guest 1" PARTICIPANT="Guest" LINGUISTIC_TYPE_REF="utterance">`)
+	assert.Contains(t, out, `<TIER TIER_ID="Guest 1 words" PARTICIPANT="Guest" LINGUISTIC_TYPE_REF="words" PARENT_REF="Guest 1">`)
+
+	decoded, _, err := DecodeEAF(buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, withoutConfidence(testTranscript), withoutConfidence(decoded))
+	}
+}
+
+func TestDecodeEAF_Unaligned(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<ANNOTATION_DOCUMENT AUTHOR="" DATE="2020-01-01T00:00:00Z" FORMAT="3.0" VERSION="3.0">
+    <HEADER MEDIA_FILE="" TIME_UNITS="milliseconds"/>
+    <TIME_ORDER>
+        <TIME_SLOT TIME_SLOT_ID="ts1" TIME_VALUE="1000"/>
+        <TIME_SLOT TIME_SLOT_ID="ts2" TIME_VALUE="2000"/>
+        <TIME_SLOT TIME_SLOT_ID="ts3"/>
+    </TIME_ORDER>
+    <TIER TIER_ID="Speaker 3" LINGUISTIC_TYPE_REF="default-lt">
+        <ANNOTATION>
+            <ALIGNABLE_ANNOTATION ANNOTATION_ID="a1" TIME_SLOT_REF1="ts1" TIME_SLOT_REF2="ts2">
+                <ANNOTATION_VALUE>Yes</ANNOTATION_VALUE>
+            </ALIGNABLE_ANNOTATION>
+        </ANNOTATION>
+        <ANNOTATION>
+            <ALIGNABLE_ANNOTATION ANNOTATION_ID="a2" TIME_SLOT_REF1="ts2" TIME_SLOT_REF2="ts3">
+                <ANNOTATION_VALUE>No</ANNOTATION_VALUE>
+            </ALIGNABLE_ANNOTATION>
+        </ANNOTATION>
+    </TIER>
+</ANNOTATION_DOCUMENT>`
+
+	decoded, opts, err := DecodeEAF(strings.NewReader(doc))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Empty(t, opts.SpeakerNames)
+	assert.Equal(t, &Transcript{Monologues: []Monologue{{
+		Speaker:  3,
+		Elements: []Element{{Type: "text", Value: "Yes", Ts: 1, EndTs: 2, Confidence: 1}},
+	}}}, decoded)
+}
@@ -0,0 +1,301 @@
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	revai "github.com/threeaccents/revai-go"
+)
+
+// The index file format is a header followed by the normalization options,
+// a table of terms and the words of each document. Numbers are varints and
+// times are milliseconds, with the start of each word stored as the
+// difference from the previous word. Postings are rebuilt when an index is
+// read.
+const (
+	magic   = "RVIX"
+	version = 1
+)
+
+const (
+	flagRemoveTags = 1 << iota
+	flagLowercase
+	flagExpandContractions
+	flagStripPunctuation
+	flagRemoveFillers
+	flagFillers
+)
+
+var errCorrupt = errors.New("corrupt index")
+
+// WriteTo writes the index to w in a compact binary format.
+func (ix *Index) WriteTo(w io.Writer) (int64, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	ew := &encoder{w: bufio.NewWriter(w)}
+
+	ew.write([]byte(magic))
+	ew.uvarint(version)
+
+	n := ix.normalize
+	var flags uint64
+	set := func(flag uint64, ok bool) {
+		if ok {
+			flags |= flag
+		}
+	}
+	set(flagRemoveTags, n.RemoveTags)
+	set(flagLowercase, n.Lowercase)
+	set(flagExpandContractions, n.ExpandContractions)
+	set(flagStripPunctuation, n.StripPunctuation)
+	set(flagRemoveFillers, n.RemoveFillers)
+	set(flagFillers, n.Fillers != nil)
+	ew.uvarint(flags)
+	ew.uvarint(uint64(n.Hyphens))
+	ew.uvarint(uint64(n.Numbers))
+	if n.Fillers != nil {
+		ew.uvarint(uint64(len(n.Fillers)))
+		for _, f := range n.Fillers {
+			ew.string(f)
+		}
+	}
+
+	terms := make([]string, 0, len(ix.postings))
+	for term := range ix.postings {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	termIDs := make(map[string]uint64, len(terms))
+	ew.uvarint(uint64(len(terms)))
+	for i, term := range terms {
+		termIDs[term] = uint64(i)
+		ew.string(term)
+	}
+
+	ids := make([]string, 0, len(ix.docs))
+	for id := range ix.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	ew.uvarint(uint64(len(ids)))
+	for _, id := range ids {
+		words := ix.docs[id]
+		ew.string(id)
+		ew.uvarint(uint64(len(words)))
+
+		var prev int64
+		for _, w := range words {
+			ts := milliseconds(w.Ts)
+			ew.uvarint(termIDs[w.Term])
+			ew.varint(int64(w.Speaker))
+			ew.varint(ts - prev)
+			ew.varint(milliseconds(w.EndTs) - ts)
+			prev = ts
+		}
+	}
+
+	if ew.err == nil {
+		ew.err = ew.w.Flush()
+	}
+	if ew.err != nil {
+		return ew.n, fmt.Errorf("failed writing index %w", ew.err)
+	}
+	return ew.n, nil
+}
+
+// Read reads an index written by WriteTo.
+func Read(r io.Reader) (*Index, error) {
+	dr := &decoder{r: bufio.NewReader(r)}
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(dr.r, header); err != nil || string(header) != magic {
+		return nil, fmt.Errorf("failed reading index %w", errCorrupt)
+	}
+	if v := dr.uvarint(); dr.err == nil && v != version {
+		return nil, fmt.Errorf("unsupported index version %d", v)
+	}
+
+	flags := dr.uvarint()
+	opts := &revai.NormalizeOptions{
+		RemoveTags:         flags&flagRemoveTags != 0,
+		Lowercase:          flags&flagLowercase != 0,
+		ExpandContractions: flags&flagExpandContractions != 0,
+		StripPunctuation:   flags&flagStripPunctuation != 0,
+		RemoveFillers:      flags&flagRemoveFillers != 0,
+		Hyphens:            revai.HyphenMode(dr.uvarint()),
+		Numbers:            revai.NumberMode(dr.uvarint()),
+	}
+	if flags&flagFillers != 0 {
+		opts.Fillers = make([]string, dr.count())
+		for i := range opts.Fillers {
+			opts.Fillers[i] = dr.string()
+		}
+	}
+
+	ix := New(opts)
+
+	terms := make([]string, dr.count())
+	for i := range terms {
+		terms[i] = dr.string()
+	}
+
+	docs := dr.count()
+	for i := 0; i < docs && dr.err == nil; i++ {
+		id := dr.string()
+		words := make([]Word, dr.count())
+
+		var ts int64
+		for j := range words {
+			term := dr.uvarint()
+			speaker := dr.varint()
+			ts += dr.varint()
+			end := ts + dr.varint()
+			if dr.err == nil && term >= uint64(len(terms)) {
+				dr.err = errCorrupt
+			}
+			if dr.err != nil {
+				break
+			}
+			words[j] = Word{Term: terms[term], Speaker: int(speaker), Ts: seconds(ts), EndTs: seconds(end)}
+		}
+		ix.add(id, words)
+	}
+
+	if dr.err != nil {
+		return nil, fmt.Errorf("failed reading index %w", dr.err)
+	}
+	return ix, nil
+}
+
+// Save writes the index to a file, replacing it once the index is written.
+func (ix *Index) Save(name string) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed saving index %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := ix.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed saving index %w", err)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return fmt.Errorf("failed saving index %w", err)
+	}
+	return nil
+}
+
+// Load reads an index saved to a file.
+func Load(name string) (*Index, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading index %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+func milliseconds(seconds float64) int64 {
+	return int64(math.Round(seconds * 1000))
+}
+
+func seconds(ms int64) float64 {
+	return float64(ms) / 1000
+}
+
+// encoder writes varints and strings, keeping the first error.
+type encoder struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	n, err := e.w.Write(b)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.write(e.buf[:binary.PutUvarint(e.buf[:], v)])
+}
+
+func (e *encoder) varint(v int64) {
+	e.write(e.buf[:binary.PutVarint(e.buf[:], v)])
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.write([]byte(s))
+}
+
+// decoder reads varints and strings, keeping the first error.
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.err = errCorrupt
+	}
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	if err != nil {
+		d.err = errCorrupt
+	}
+	return v
+}
+
+// count reads a length, guarding against lengths a corrupt file could use
+// to allocate too much memory.
+func (d *decoder) count() int {
+	v := d.uvarint()
+	if v > 1<<24 {
+		d.err = errCorrupt
+		return 0
+	}
+	return int(v)
+}
+
+func (d *decoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		d.err = errCorrupt
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,193 @@
+// Package index is a positional inverted index for searching across many
+// Rev.ai transcripts by word, phrase, proximity and speaker.
+package index
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	revai "github.com/threeaccents/revai-go"
+)
+
+// Word is an indexed word of a transcript.
+type Word struct {
+	Term    string
+	Speaker int
+	Ts      float64
+	EndTs   float64
+}
+
+// Hit is a match of a query in a transcript.
+type Hit struct {
+	JobID   string  `json:"job_id"`
+	Speaker int     `json:"speaker"`
+	Ts      float64 `json:"ts"`
+	EndTs   float64 `json:"end_ts"`
+
+	// Text is the normalized words of the match.
+	Text string `json:"text"`
+}
+
+// Index is a positional inverted index of transcripts keyed by job ID. It is
+// safe for concurrent use.
+type Index struct {
+	mu        sync.RWMutex
+	normalize revai.NormalizeOptions
+	docs      map[string][]Word
+
+	// postings holds the positions of each term in each document.
+	postings map[string]map[string][]int
+}
+
+// New returns an empty index normalizing transcripts and queries with opts.
+// A nil opts uses revai.StandardNormalization.
+func New(opts *revai.NormalizeOptions) *Index {
+	if opts == nil {
+		opts = &revai.StandardNormalization
+	}
+
+	normalize := *opts
+	if opts.Fillers != nil {
+		normalize.Fillers = append([]string{}, opts.Fillers...)
+	}
+
+	return &Index{
+		normalize: normalize,
+		docs:      map[string][]Word{},
+		postings:  map[string]map[string][]int{},
+	}
+}
+
+// Add indexes the transcript of a job, replacing any transcript already
+// indexed for it.
+func (ix *Index) Add(jobID string, t *revai.Transcript) {
+	var words []Word
+	for _, w := range t.Normalize(&ix.normalize) {
+		words = append(words, Word{Term: w.Text, Speaker: w.Speaker, Ts: w.Ts, EndTs: w.EndTs})
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.delete(jobID)
+	ix.add(jobID, words)
+}
+
+func (ix *Index) add(jobID string, words []Word) {
+	ix.docs[jobID] = words
+	for pos, w := range words {
+		docs, ok := ix.postings[w.Term]
+		if !ok {
+			docs = map[string][]int{}
+			ix.postings[w.Term] = docs
+		}
+		docs[jobID] = append(docs[jobID], pos)
+	}
+}
+
+// Delete removes the transcript of a job from the index.
+func (ix *Index) Delete(jobID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.delete(jobID)
+}
+
+func (ix *Index) delete(jobID string) {
+	for _, w := range ix.docs[jobID] {
+		if docs, ok := ix.postings[w.Term]; ok {
+			delete(docs, jobID)
+			if len(docs) == 0 {
+				delete(ix.postings, w.Term)
+			}
+		}
+	}
+	delete(ix.docs, jobID)
+}
+
+// Has reports whether a transcript is indexed for the job.
+func (ix *Index) Has(jobID string) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	_, ok := ix.docs[jobID]
+	return ok
+}
+
+// Len returns the number of indexed transcripts.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.docs)
+}
+
+// JobIDs returns the IDs of the indexed jobs in order.
+func (ix *Index) JobIDs() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	ids := make([]string, 0, len(ix.docs))
+	for id := range ix.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Words returns the indexed words of a job.
+func (ix *Index) Words(jobID string) []Word {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return append([]Word{}, ix.docs[jobID]...)
+}
+
+// Search returns the matches of query ordered by job ID and time.
+//
+// Words in a query must all match, or either may match when joined with
+// OR. Words prefixed with - or NOT must not be in a transcript. Parentheses
+// group words, "quoted words" match a phrase and "quoted words"~N match the
+// words in any order within N extra words of each other. speaker:N limits
+// matches to the words of a speaker, and * and ? are wildcards within a word.
+// Phrases and proximity matches do not span speakers.
+func (ix *Index) Search(query string) ([]Hit, error) {
+	n, err := ix.parse(query)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var hits []Hit
+	for id, spans := range n.eval(ix) {
+		words := ix.docs[id]
+		for _, s := range spans {
+			terms := make([]string, 0, s.end-s.start+1)
+			for _, w := range words[s.start : s.end+1] {
+				terms = append(terms, w.Term)
+			}
+			hits = append(hits, Hit{
+				JobID:   id,
+				Speaker: words[s.start].Speaker,
+				Ts:      words[s.start].Ts,
+				EndTs:   words[s.end].EndTs,
+				Text:    strings.Join(terms, " "),
+			})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].JobID != hits[j].JobID {
+			return hits[i].JobID < hits[j].JobID
+		}
+		return hits[i].Ts < hits[j].Ts
+	})
+
+	return hits, nil
+}
@@ -0,0 +1,164 @@
+package index
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	revai "github.com/threeaccents/revai-go"
+)
+
+// makeTranscript returns a transcript with a monologue per line, each line
+// starting with its speaker number and each word lasting half a second.
+func makeTranscript(lines ...string) *revai.Transcript {
+	t := &revai.Transcript{}
+	ts := 0.0
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		m := revai.Monologue{Speaker: int(fields[0][0] - '0')}
+		for i, word := range fields[1:] {
+			if i > 0 {
+				m.Elements = append(m.Elements, revai.Element{Type: "punct", Value: " "})
+			}
+			m.Elements = append(m.Elements, revai.Element{Type: "text", Value: word, Ts: ts, EndTs: ts + 0.5})
+			ts += 0.5
+		}
+		t.Monologues = append(t.Monologues, m)
+	}
+	return t
+}
+
+func testIndex() *Index {
+	ix := New(nil)
+	ix.Add("job-1", makeTranscript("0 Hello, thanks for calling support.", "1 Hi, my internet is down again."))
+	ix.Add("job-2", makeTranscript("0 Support here.", "1 My phone bill is wrong, the internet bill too."))
+	ix.Add("job-3", makeTranscript("1 Thanks for the help with my internet."))
+	return ix
+}
+
+func searchTexts(t *testing.T, ix *Index, query string) []string {
+	hits, err := ix.Search(query)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	var texts []string
+	for _, hit := range hits {
+		texts = append(texts, hit.JobID+": "+hit.Text)
+	}
+	return texts
+}
+
+func TestIndex_Search(t *testing.T) {
+	ix := testIndex()
+
+	hits, err := ix.Search(`"internet is down"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []Hit{{JobID: "job-1", Speaker: 1, Ts: 3.5, EndTs: 5, Text: "internet is down"}}, hits)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"internet", []string{"job-1: internet", "job-2: internet", "job-3: internet"}},
+		{"internet bill", []string{"job-2: bill", "job-2: internet", "job-2: bill"}},
+		{"internet AND support", []string{"job-1: support", "job-1: internet", "job-2: support", "job-2: internet"}},
+		{"phone OR down", []string{"job-1: down", "job-2: phone"}},
+		{"internet -support", []string{"job-3: internet"}},
+		{"internet NOT (phone OR down)", []string{"job-3: internet"}},
+		{`"thanks calling"~1`, []string{"job-1: thanks for calling"}},
+		{`"calling thanks"~1`, []string{"job-1: thanks for calling"}},
+		{`"thanks calling"~0`, nil},
+		{"speaker:0 thanks", []string{"job-1: thanks"}},
+		{"speaker:1 support", nil},
+		{"inter*", []string{"job-1: internet", "job-2: internet", "job-3: internet"}},
+		{"um", nil},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, searchTexts(t, ix, tt.query))
+		})
+	}
+}
+
+func TestIndex_Search_Invalid(t *testing.T) {
+	ix := testIndex()
+
+	for _, query := range []string{`"unterminated`, "(internet", "internet)", "-internet", "speaker:x", "OR internet", `"a b"~x`} {
+		_, err := ix.Search(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestIndex_AddDelete(t *testing.T) {
+	ix := testIndex()
+	assert.Equal(t, 3, ix.Len())
+	assert.Equal(t, []string{"job-1", "job-2", "job-3"}, ix.JobIDs())
+
+	ix.Delete("job-2")
+	assert.False(t, ix.Has("job-2"))
+	assert.Equal(t, []string{"job-1: internet", "job-3: internet"}, searchTexts(t, ix, "internet"))
+	assert.Nil(t, searchTexts(t, ix, "phone"))
+	assert.NotContains(t, ix.postings, "phone")
+
+	// adding a job again replaces its transcript.
+	ix.Add("job-1", makeTranscript("0 Goodbye."))
+	assert.Equal(t, []string{"job-3: internet"}, searchTexts(t, ix, "internet"))
+	assert.Equal(t, []Word{{Term: "goodbye", Ts: 0, EndTs: 0.5}}, ix.Words("job-1"))
+}
+
+func TestIndex_WriteTo(t *testing.T) {
+	ix := New(&revai.NormalizeOptions{Lowercase: true, StripPunctuation: true, RemoveFillers: true, Fillers: []string{"well"}, Numbers: revai.WordsToNumbers})
+	ix.Add("job-1", makeTranscript("0 Well, twenty one people called.", "2 Then more."))
+	ix.Add("job-2", makeTranscript("1 Nobody called."))
+
+	var buf bytes.Buffer
+	n, err := ix.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	read, err := Read(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, ix.normalize, read.normalize)
+	assert.Equal(t, ix.docs, read.docs)
+	assert.Equal(t, ix.postings, read.postings)
+	assert.Equal(t, []string{"job-1: 21 people"}, searchTexts(t, read, `"twenty one people"`))
+
+	_, err = Read(strings.NewReader("RVIX\x01\x00"))
+	assert.Error(t, err)
+	_, err = Read(strings.NewReader("nope"))
+	assert.Error(t, err)
+}
+
+func TestIndex_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "transcripts.idx")
+	ix := testIndex()
+	assert.NoError(t, ix.Save(name))
+
+	loaded, err := Load(name)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ix.JobIDs(), loaded.JobIDs())
+		assert.Equal(t, searchTexts(t, ix, "internet"), searchTexts(t, loaded, "internet"))
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	_, err = Load(filepath.Join(dir, "missing.idx"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,495 @@
+package index
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	revai "github.com/threeaccents/revai-go"
+)
+
+// span is the first and last word positions of a match.
+type span struct {
+	start, end int
+}
+
+// result holds the matches of a query node in each document.
+type result map[string][]span
+
+type node interface {
+	eval(ix *Index) result
+}
+
+type (
+	// termNode matches any of its terms.
+	termNode struct{ terms []string }
+
+	// wildcardNode matches the terms matching its pattern.
+	wildcardNode struct{ pattern string }
+
+	// phraseNode matches its terms in order.
+	phraseNode struct{ terms []string }
+
+	// nearNode matches its terms in any order within slop extra words.
+	nearNode struct {
+		terms []string
+		slop  int
+	}
+
+	// speakerNode matches the turns of a speaker.
+	speakerNode struct{ speaker int }
+
+	// andNode matches documents matching all of nodes and none of not.
+	andNode struct{ nodes, not []node }
+
+	// orNode matches documents matching any of nodes.
+	orNode struct{ nodes []node }
+)
+
+func (n termNode) eval(ix *Index) result {
+	res := result{}
+	for _, term := range n.terms {
+		for id, positions := range ix.postings[term] {
+			for _, pos := range positions {
+				res[id] = append(res[id], span{pos, pos})
+			}
+		}
+	}
+	return res.merged()
+}
+
+func (n wildcardNode) eval(ix *Index) result {
+	var terms []string
+	for term := range ix.postings {
+		if ok, _ := path.Match(n.pattern, term); ok {
+			terms = append(terms, term)
+		}
+	}
+	return termNode{terms}.eval(ix)
+}
+
+func (n phraseNode) eval(ix *Index) result {
+	res := result{}
+	for id, positions := range ix.postings[n.terms[0]] {
+		words := ix.docs[id]
+		for _, pos := range positions {
+			end := pos + len(n.terms) - 1
+			if end >= len(words) || !sameSpeaker(words, pos, end) {
+				continue
+			}
+			match := true
+			for i, term := range n.terms[1:] {
+				if words[pos+i+1].Term != term {
+					match = false
+					break
+				}
+			}
+			if match {
+				res[id] = append(res[id], span{pos, end})
+			}
+		}
+	}
+	return res.merged()
+}
+
+func (n nearNode) eval(ix *Index) result {
+	required := map[string]int{}
+	for _, term := range n.terms {
+		required[term]++
+	}
+
+	type event struct {
+		pos  int
+		term string
+	}
+
+	res := result{}
+	for id := range ix.postings[n.terms[0]] {
+		var events []event
+		for term := range required {
+			positions, ok := ix.postings[term][id]
+			if !ok {
+				events = nil
+				break
+			}
+			for _, pos := range positions {
+				events = append(events, event{pos, term})
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+		sort.Slice(events, func(i, j int) bool { return events[i].pos < events[j].pos })
+
+		// the window of events from left to right is shrunk from the left
+		// while it holds all terms, matches do not overlap.
+		words := ix.docs[id]
+		counts := map[string]int{}
+		satisfied := 0
+		left := 0
+		for right := 0; right < len(events); right++ {
+			counts[events[right].term]++
+			if counts[events[right].term] == required[events[right].term] {
+				satisfied++
+			}
+
+			for satisfied == len(required) {
+				start, end := events[left].pos, events[right].pos
+				if end-start <= len(n.terms)-1+n.slop && sameSpeaker(words, start, end) {
+					res[id] = append(res[id], span{start, end})
+					counts = map[string]int{}
+					satisfied = 0
+					left = right + 1
+					break
+				}
+				if counts[events[left].term] == required[events[left].term] {
+					satisfied--
+				}
+				counts[events[left].term]--
+				left++
+			}
+		}
+	}
+	return res
+}
+
+func (n speakerNode) eval(ix *Index) result {
+	res := result{}
+	for id, words := range ix.docs {
+		for i := 0; i < len(words); i++ {
+			if words[i].Speaker != n.speaker {
+				continue
+			}
+			start := i
+			for i+1 < len(words) && words[i+1].Speaker == n.speaker {
+				i++
+			}
+			res[id] = append(res[id], span{start, i})
+		}
+	}
+	return res
+}
+
+func (n andNode) eval(ix *Index) result {
+	var (
+		res      result
+		speakers []speakerNode
+	)
+
+	for _, child := range n.nodes {
+		if s, ok := child.(speakerNode); ok {
+			speakers = append(speakers, s)
+			continue
+		}
+
+		r := child.eval(ix)
+		if res == nil {
+			res = r
+			continue
+		}
+		for id, spans := range res {
+			if other, ok := r[id]; ok {
+				res[id] = append(spans, other...)
+			} else {
+				delete(res, id)
+			}
+		}
+	}
+
+	if res == nil {
+		res = speakers[0].eval(ix)
+	}
+
+	// speakers limit the matches of the other nodes to their words.
+	for _, s := range speakers {
+		for id, spans := range res {
+			words := ix.docs[id]
+			var kept []span
+			for _, sp := range spans {
+				if words[sp.start].Speaker == s.speaker && sameSpeaker(words, sp.start, sp.end) {
+					kept = append(kept, sp)
+				}
+			}
+			if len(kept) == 0 {
+				delete(res, id)
+			} else {
+				res[id] = kept
+			}
+		}
+	}
+
+	for _, child := range n.not {
+		for id := range child.eval(ix) {
+			delete(res, id)
+		}
+	}
+
+	return res.merged()
+}
+
+func (n orNode) eval(ix *Index) result {
+	res := result{}
+	for _, child := range n.nodes {
+		for id, spans := range child.eval(ix) {
+			res[id] = append(res[id], spans...)
+		}
+	}
+	return res.merged()
+}
+
+// merged sorts the spans of each document and merges overlapping spans.
+func (r result) merged() result {
+	for id, spans := range r {
+		sort.Slice(spans, func(i, j int) bool {
+			if spans[i].start != spans[j].start {
+				return spans[i].start < spans[j].start
+			}
+			return spans[i].end < spans[j].end
+		})
+
+		merged := spans[:1]
+		for _, s := range spans[1:] {
+			last := &merged[len(merged)-1]
+			if s.start > last.end {
+				merged = append(merged, s)
+			} else if s.end > last.end {
+				last.end = s.end
+			}
+		}
+		r[id] = merged
+	}
+	return r
+}
+
+func sameSpeaker(words []Word, start, end int) bool {
+	for _, w := range words[start+1 : end+1] {
+		if w.Speaker != words[start].Speaker {
+			return false
+		}
+	}
+	return true
+}
+
+// token is a lexical token of a query. Phrases are quoted and keep their
+// slop, operators and parentheses are returned as themselves.
+type token struct {
+	text   string
+	phrase bool
+	slop   int
+	near   bool
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '-' && i+1 < len(query) && query[i+1] != ' ':
+			tokens = append(tokens, token{text: "NOT"})
+			i++
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid query, unterminated phrase")
+			}
+			t := token{text: query[i+1 : i+1+end], phrase: true}
+			i += end + 2
+
+			if i < len(query) && query[i] == '~' {
+				j := i + 1
+				for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+					j++
+				}
+				slop, err := strconv.Atoi(query[i+1 : j])
+				if err != nil {
+					return nil, fmt.Errorf("invalid query, proximity must be a number of words")
+				}
+				t.slop, t.near = slop, true
+				i = j
+			}
+			tokens = append(tokens, t)
+		default:
+			j := i
+			for j < len(query) && !strings.ContainsRune(" \t\n\r()\"", rune(query[j])) {
+				j++
+			}
+			tokens = append(tokens, token{text: query[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser of queries. Nodes of words removed by
+// normalization, such as fillers, are nil and left out.
+type parser struct {
+	ix     *Index
+	tokens []token
+	pos    int
+}
+
+func (ix *Index) parse(query string) (node, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{ix: ix, tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid query, unexpected %q", p.tokens[p.pos].text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].phrase && p.tokens[p.pos].text == op
+}
+
+func (p *parser) or() (node, error) {
+	var nodes []node
+	for {
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+		if !p.peek("OR") {
+			break
+		}
+		p.pos++
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+	return orNode{nodes}, nil
+}
+
+func (p *parser) and() (node, error) {
+	var (
+		n        andNode
+		operands int
+	)
+
+	for p.pos < len(p.tokens) && !p.peek("OR") && !p.peek(")") {
+		if p.peek("AND") {
+			p.pos++
+			continue
+		}
+
+		not := false
+		for p.peek("NOT") {
+			p.pos++
+			not = !not
+		}
+
+		child, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		operands++
+		if child == nil {
+			continue
+		}
+		if not {
+			n.not = append(n.not, child)
+		} else {
+			n.nodes = append(n.nodes, child)
+		}
+	}
+
+	if operands == 0 {
+		return nil, fmt.Errorf("invalid query, expected a word")
+	}
+	if len(n.nodes) == 0 {
+		if len(n.not) > 0 {
+			return nil, fmt.Errorf("invalid query, NOT must be used with another word")
+		}
+		return nil, nil
+	}
+	if len(n.nodes) == 1 && len(n.not) == 0 {
+		return n.nodes[0], nil
+	}
+	return n, nil
+}
+
+func (p *parser) primary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("invalid query, expected a word")
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+
+	if t.phrase {
+		terms := revai.NormalizeText(t.text, &p.ix.normalize)
+		switch {
+		case len(terms) == 0:
+			return nil, nil
+		case t.near:
+			return nearNode{terms, t.slop}, nil
+		case len(terms) == 1:
+			return termNode{terms}, nil
+		}
+		return phraseNode{terms}, nil
+	}
+
+	switch t.text {
+	case "(":
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("invalid query, missing )")
+		}
+		p.pos++
+		return n, nil
+	case ")", "AND", "OR", "NOT":
+		return nil, fmt.Errorf("invalid query, unexpected %q", t.text)
+	}
+
+	if len(t.text) > len("speaker:") && strings.EqualFold(t.text[:len("speaker:")], "speaker:") {
+		speaker, err := strconv.Atoi(t.text[len("speaker:"):])
+		if err != nil {
+			return nil, fmt.Errorf("invalid query, %q is not a speaker number", t.text)
+		}
+		return speakerNode{speaker}, nil
+	}
+
+	if strings.ContainsAny(t.text, "*?") {
+		pattern := t.text
+		if p.ix.normalize.Lowercase {
+			pattern = strings.ToLower(pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid query, bad wildcard %q", t.text)
+		}
+		return wildcardNode{pattern}, nil
+	}
+
+	terms := revai.NormalizeText(t.text, &p.ix.normalize)
+	switch len(terms) {
+	case 0:
+		return nil, nil
+	case 1:
+		return termNode{terms}, nil
+	}
+	return phraseNode{terms}, nil
+}
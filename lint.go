@@ -0,0 +1,194 @@
+package revai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LintSeverity is how serious a caption lint finding is.
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+)
+
+// LintRule identifies the check that produced a lint finding.
+type LintRule string
+
+const (
+	LintOverlap    LintRule = "overlap"
+	LintDuration   LintRule = "duration"
+	LintCPS        LintRule = "cps"
+	LintLineLength LintRule = "line-length"
+	LintLineCount  LintRule = "line-count"
+	LintGap        LintRule = "gap"
+	LintEmpty      LintRule = "empty"
+)
+
+// LintFinding is a single issue found by LintCues.
+type LintFinding struct {
+	CueIndex int          `json:"cue_index"`
+	Rule     LintRule     `json:"rule"`
+	Severity LintSeverity `json:"severity"`
+	Message  string       `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("cue %d: %s: %s (%s)", f.CueIndex, f.Severity, f.Message, f.Rule)
+}
+
+// LintCues checks cues against style and returns the issues found ordered by
+// cue index. Text is measured without markup. A nil style uses NetflixCaptionStyle.
+func LintCues(cues []Cue, style *CaptionStyle) []LintFinding {
+	s := style.withDefaults()
+	minGap := s.minGap()
+
+	var findings []LintFinding
+	add := func(i int, rule LintRule, severity LintSeverity, format string, args ...interface{}) {
+		findings = append(findings, LintFinding{
+			CueIndex: i,
+			Rule:     rule,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, cue := range cues {
+		lines := cue.PlainLines()
+		chars := plainChars(lines)
+
+		if chars == 0 {
+			add(i, LintEmpty, LintError, "cue has no text")
+		}
+
+		if cue.Duration() <= 0 {
+			add(i, LintDuration, LintError, "cue duration %s is not positive", cue.Duration())
+		} else if chars > 0 && s.MaxCPS > 0 {
+			if cps := float64(chars) / cue.Duration().Seconds(); cps > s.MaxCPS {
+				add(i, LintCPS, LintWarning, "reading speed %.1f cps is over %.1f cps", cps, s.MaxCPS)
+			}
+		}
+
+		if len(lines) > s.MaxLines {
+			add(i, LintLineCount, LintError, "cue has %d lines, more than %d", len(lines), s.MaxLines)
+		}
+
+		for n, line := range lines {
+			if l := utf8.RuneCountInString(line); l > s.MaxCharsPerLine {
+				add(i, LintLineLength, LintError, "line %d has %d characters, more than %d", n+1, l, s.MaxCharsPerLine)
+			}
+		}
+
+		if i+1 < len(cues) {
+			gap := cues[i+1].Start - cue.End
+			switch {
+			case gap < 0:
+				add(i, LintOverlap, LintError, "cue overlaps the next cue by %s", -gap)
+			case gap < minGap:
+				add(i, LintGap, LintWarning, "gap of %s to the next cue is under %d frames", gap, s.MinGapFrames)
+			}
+		}
+	}
+
+	return findings
+}
+
+// FixCues returns a copy of cues with the mechanical issues found by LintCues
+// fixed. Empty cues are removed, cues are sorted by start time, cues without a
+// positive duration are given one, overlaps and short gaps are closed by ending
+// cues earlier, or by starting the next cue later when it starts too soon
+// after the cue to end it earlier, and lines without markup are re-wrapped
+// when too long.
+// Reading speed issues need editing and are left as they are.
+// A nil style uses NetflixCaptionStyle.
+func FixCues(cues []Cue, style *CaptionStyle) []Cue {
+	s := style.withDefaults()
+	minGap := s.minGap()
+
+	var fixed []Cue
+	for _, cue := range cues {
+		if plainChars(cue.PlainLines()) == 0 {
+			continue
+		}
+		c := cue
+		c.Lines = append([]string(nil), cue.Lines...)
+		fixed = append(fixed, c)
+	}
+
+	sort.SliceStable(fixed, func(i, j int) bool {
+		return fixed[i].Start < fixed[j].Start
+	})
+
+	for i := range fixed {
+		cue := &fixed[i]
+
+		if cue.Duration() <= 0 {
+			cue.End = cue.Start + s.readingTime(plainChars(cue.PlainLines()))
+		}
+
+		if i+1 < len(fixed) {
+			next := &fixed[i+1]
+			if limit := next.Start - minGap; cue.End > limit {
+				if limit > cue.Start {
+					cue.End = limit
+				} else {
+					// the next cue keeps its end unless it is shifted past
+					// it, then its duration is fixed in turn.
+					next.Start = cue.End + minGap
+				}
+			}
+		}
+
+		if strings.Join(cue.PlainLines(), "\n") != cue.Text() {
+			continue
+		}
+
+		tooLong := false
+		for _, line := range cue.Lines {
+			if utf8.RuneCountInString(line) > s.MaxCharsPerLine {
+				tooLong = true
+			}
+		}
+
+		if tooLong || len(cue.Lines) > s.MaxLines {
+			if lines := wrapText(cue.Text(), s.MaxCharsPerLine); len(lines) <= s.MaxLines || tooLong {
+				cue.Lines = lines
+			}
+		}
+	}
+
+	return fixed
+}
+
+func (s CaptionStyle) minGap() time.Duration {
+	return Timecode{Frames: s.MinGapFrames, Rate: s.FrameRate}.Duration()
+}
+
+// readingTime is how long text of the given length should be displayed for.
+func (s CaptionStyle) readingTime(chars int) time.Duration {
+	d := s.MinDuration
+	if s.MaxCPS > 0 {
+		if reading := time.Duration(float64(chars) / s.MaxCPS * float64(time.Second)); reading > d {
+			d = reading
+		}
+	}
+	if d > s.MaxDuration {
+		d = s.MaxDuration
+	}
+	if d <= 0 {
+		d = time.Second
+	}
+	return d
+}
+
+func plainChars(lines []string) int {
+	chars := 0
+	for _, line := range lines {
+		chars += utf8.RuneCountInString(strings.TrimSpace(line))
+	}
+	return chars
+}
@@ -0,0 +1,87 @@
+package revai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLintCues = []Cue{
+	{Start: 0, End: 2 * time.Second, Lines: []string{"Hello there."}},
+	{Start: 1500 * time.Millisecond, End: 3 * time.Second, Lines: []string{"This overlaps."}},
+	{Start: 3050 * time.Millisecond, End: 3 * time.Second, Lines: []string{"Backwards."}},
+	{Start: 4 * time.Second, End: 5 * time.Second, Lines: []string{"<i></i>"}},
+	{Start: 5 * time.Second, End: 6 * time.Second, Lines: []string{"This line is much too long to fit on a single caption row", "and", "there are three lines"}},
+}
+
+func TestLintCues(t *testing.T) {
+	findings := LintCues(testLintCues, nil)
+
+	var rules []LintRule
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+
+	assert.Equal(t, []LintRule{
+		LintOverlap,
+		LintGap,
+		LintDuration,
+		LintEmpty,
+		LintGap,
+		LintCPS,
+		LintLineCount,
+		LintLineLength,
+	}, rules)
+
+	assert.Equal(t, 0, findings[0].CueIndex)
+	assert.Equal(t, LintError, findings[0].Severity)
+	assert.Equal(t, "cue overlaps the next cue by 500ms", findings[0].Message)
+	assert.Equal(t, 1, findings[1].CueIndex)
+	assert.Equal(t, LintWarning, findings[1].Severity)
+	assert.Equal(t, 4, findings[len(findings)-1].CueIndex)
+}
+
+func TestFixCues(t *testing.T) {
+	fixed := FixCues(testLintCues, nil)
+
+	assert.Len(t, fixed, 4)
+	assert.Equal(t, 3, len(fixed[3].Lines))
+
+	findings := LintCues(fixed, nil)
+	for _, f := range findings {
+		assert.Contains(t, []LintRule{LintCPS, LintLineCount}, f.Rule, f.String())
+	}
+
+	// the input is not modified.
+	assert.Equal(t, 2*time.Second, testLintCues[0].End)
+}
+
+func TestFixCues_SameStart(t *testing.T) {
+	cues := []Cue{
+		{Start: time.Second, End: 3 * time.Second, Lines: []string{"Hello there"}},
+		{Start: time.Second, End: 2 * time.Second, Lines: []string{"Hi"}},
+		{Start: 3 * time.Second, End: 6 * time.Second, Lines: []string{"How are you?"}},
+	}
+
+	// cues starting with or before the cue before them start after it.
+	fixed := FixCues(cues, nil)
+	if assert.Len(t, fixed, 3) {
+		assert.Equal(t, time.Second, fixed[0].Start)
+		assert.Equal(t, 3*time.Second, fixed[0].End)
+		assert.True(t, fixed[1].Start > fixed[0].End)
+		assert.True(t, fixed[1].Duration() > 0)
+	}
+	for _, f := range LintCues(fixed, nil) {
+		assert.NotContains(t, []LintRule{LintOverlap, LintGap, LintDuration}, f.Rule, f.String())
+	}
+}
+
+func TestFixCues_Wrap(t *testing.T) {
+	cues := []Cue{{Start: 0, End: 5 * time.Second, Lines: []string{"This line is much too long to fit on a single caption row"}}}
+
+	fixed := FixCues(cues, nil)
+
+	assert.Equal(t, []string{"This line is much too long to fit on a", "single caption row"}, fixed[0].Lines)
+	assert.Empty(t, LintCues(fixed, nil))
+}
@@ -0,0 +1,366 @@
+package revai
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marker is a named span of media placed on an editing timeline.
+type Marker struct {
+	Start   time.Duration
+	End     time.Duration
+	Name    string
+	Comment string
+}
+
+// Markers returns a marker for each monologue of the transcript named after
+// its speaker with the monologue text as the comment. speakerNames maps
+// Monologue.Speaker to the name of its markers, speakers without a name are
+// called "Speaker N".
+func (t *Transcript) Markers(speakerNames map[int]string) []Marker {
+	var markers []Marker
+
+	for _, monologue := range t.Monologues {
+		words := transcriptWords(&Transcript{Monologues: []Monologue{monologue}})
+
+		name := speakerNames[monologue.Speaker]
+		if name == "" {
+			name = "Speaker " + strconv.Itoa(monologue.Speaker)
+		}
+
+		marker := Marker{Name: name}
+		timed := false
+		for _, w := range words {
+			if !w.timed {
+				continue
+			}
+			if !timed {
+				marker.Start = w.start
+				timed = true
+			}
+			marker.End = w.end
+		}
+		if !timed {
+			continue
+		}
+
+		marker.Comment = joinWords(words)
+		markers = append(markers, marker)
+	}
+
+	return markers
+}
+
+// SpanMarker returns a marker from start to end with the text of the
+// transcript said between them as the comment.
+func (t *Transcript) SpanMarker(start, end time.Duration, name string) Marker {
+	var words []captionWord
+	for _, w := range transcriptWords(t) {
+		if w.timed && w.start >= start && w.end <= end {
+			words = append(words, w)
+		}
+	}
+
+	return Marker{Start: start, End: end, Name: name, Comment: joinWords(words)}
+}
+
+// MarkerColor is the color of a marker in the editing application.
+type MarkerColor string
+
+const (
+	MarkerBlue   MarkerColor = "Blue"
+	MarkerCyan   MarkerColor = "Cyan"
+	MarkerGreen  MarkerColor = "Green"
+	MarkerYellow MarkerColor = "Yellow"
+	MarkerRed    MarkerColor = "Red"
+	MarkerPink   MarkerColor = "Pink"
+	MarkerPurple MarkerColor = "Purple"
+)
+
+// MarkerOptions specifies how markers are written by the marker encoders.
+type MarkerOptions struct {
+	// FrameRate is the frame rate of the timeline.
+	// It defaults to FrameRate23976.
+	FrameRate FrameRate
+
+	// StartTimecode is the timecode of the start of the timeline,
+	// for example 01:00:00:00.
+	StartTimecode Timecode
+
+	// Title names the EDL or the FCPXML project.
+	// It defaults to "Rev.ai transcript".
+	Title string
+
+	// Color is the marker color. It defaults to MarkerBlue.
+	Color MarkerColor
+}
+
+func (o *MarkerOptions) withDefaults() MarkerOptions {
+	opts := MarkerOptions{
+		FrameRate: FrameRate23976,
+		Title:     "Rev.ai transcript",
+		Color:     MarkerBlue,
+	}
+
+	if o == nil {
+		return opts
+	}
+
+	if !o.FrameRate.IsZero() {
+		opts.FrameRate = o.FrameRate
+	}
+	if o.Title != "" {
+		opts.Title = o.Title
+	}
+	if o.Color != "" {
+		opts.Color = o.Color
+	}
+	opts.StartTimecode = o.StartTimecode.Convert(opts.FrameRate, TimecodeRoundNearest)
+
+	return opts
+}
+
+// span returns the timecodes of the first frame of m and the frame after it.
+// Markers last at least one frame.
+func (o MarkerOptions) span(m Marker) (Timecode, Timecode) {
+	in := o.StartTimecode.Add(NewTimecode(m.Start, o.FrameRate, TimecodeRoundNearest).Frames)
+	out := o.StartTimecode.Add(NewTimecode(m.End, o.FrameRate, TimecodeRoundNearest).Frames)
+	if out.Frames <= in.Frames {
+		out = in.Add(1)
+	}
+	return in, out
+}
+
+func (o MarkerOptions) fcm() string {
+	if o.FrameRate.DropFrame {
+		return "DROP FRAME"
+	}
+	return "NON-DROP FRAME"
+}
+
+// edlMaxEvents is the most events an EDL can number.
+const edlMaxEvents = 999
+
+// EncodeEDL writes markers to w as a CMX3600 edit decision list with an event
+// for each marker and a locator comment holding its name. EDLs hold at most
+// 999 events, more markers return an error.
+func EncodeEDL(w io.Writer, markers []Marker, opts *MarkerOptions) error {
+	o := opts.withDefaults()
+
+	if len(markers) > edlMaxEvents {
+		return fmt.Errorf("too many markers for an edl %d, at most %d", len(markers), edlMaxEvents)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "TITLE: %s\n", edlText(o.Title))
+	fmt.Fprintf(bw, "FCM: %s\n", o.fcm())
+
+	for i, m := range markers {
+		in, out := o.span(m)
+
+		fmt.Fprintf(bw, "\n%03d  AX       V     C        %s %s %s %s\n", i+1, in, out, in, out)
+		fmt.Fprintf(bw, "* FROM CLIP NAME: %s\n", edlText(m.Name))
+		fmt.Fprintf(bw, "* LOC: %s %-7s %s\n", in, strings.ToUpper(string(o.Color)), edlText(m.Name))
+		if m.Comment != "" {
+			fmt.Fprintf(bw, "* COMMENT: %s\n", edlText(m.Comment))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// EncodeResolveEDL writes markers to w as an EDL that DaVinci Resolve imports
+// as timeline markers. EDLs hold at most 999 events, more markers return an
+// error.
+func EncodeResolveEDL(w io.Writer, markers []Marker, opts *MarkerOptions) error {
+	o := opts.withDefaults()
+
+	if len(markers) > edlMaxEvents {
+		return fmt.Errorf("too many markers for an edl %d, at most %d", len(markers), edlMaxEvents)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "TITLE: %s\n", edlText(o.Title))
+	fmt.Fprintf(bw, "FCM: %s\n", o.fcm())
+
+	for i, m := range markers {
+		in, out := o.span(m)
+
+		fmt.Fprintf(bw, "\n%03d  001      V     C        %s %s %s %s  \n", i+1, in, in.Add(1), in, in.Add(1))
+		fmt.Fprintf(bw, "%s |C:ResolveColor%s |M:%s |D:%d\n",
+			edlText(m.Comment), o.Color, strings.ReplaceAll(edlText(m.Name), "|", "/"), out.Sub(in))
+	}
+
+	return bw.Flush()
+}
+
+// EncodePremiereMarkers writes markers to w as a CSV file with the columns
+// of the Adobe Premiere Pro markers panel.
+func EncodePremiereMarkers(w io.Writer, markers []Marker, opts *MarkerOptions) error {
+	o := opts.withDefaults()
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"Marker Name", "Description", "In", "Out", "Duration", "Marker Type"}); err != nil {
+		return err
+	}
+
+	for _, m := range markers {
+		in, out := o.span(m)
+		duration := Timecode{Frames: out.Sub(in), Rate: o.FrameRate}
+
+		record := []string{m.Name, m.Comment, in.String(), out.String(), duration.String(), "Comment"}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type fcpxml struct {
+	XMLName   xml.Name       `xml:"fcpxml"`
+	Version   string         `xml:"version,attr"`
+	Resources fcpxmlResource `xml:"resources"`
+	Event     fcpxmlEvent    `xml:"library>event"`
+}
+
+type fcpxmlResource struct {
+	Format fcpxmlFormat `xml:"format"`
+}
+
+type fcpxmlFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxmlEvent struct {
+	Name    string        `xml:"name,attr"`
+	Project fcpxmlProject `xml:"project"`
+}
+
+type fcpxmlProject struct {
+	Name     string         `xml:"name,attr"`
+	Sequence fcpxmlSequence `xml:"sequence"`
+}
+
+type fcpxmlSequence struct {
+	Format   string    `xml:"format,attr"`
+	Duration string    `xml:"duration,attr"`
+	TCStart  string    `xml:"tcStart,attr"`
+	TCFormat string    `xml:"tcFormat,attr"`
+	Gap      fcpxmlGap `xml:"spine>gap"`
+}
+
+type fcpxmlGap struct {
+	Name     string         `xml:"name,attr"`
+	Offset   string         `xml:"offset,attr"`
+	Start    string         `xml:"start,attr"`
+	Duration string         `xml:"duration,attr"`
+	Markers  []fcpxmlMarker `xml:"marker"`
+}
+
+type fcpxmlMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+	Note     string `xml:"note,attr,omitempty"`
+}
+
+// EncodeFCPXML writes markers to w as a Final Cut Pro XML project with a
+// timeline the length of the last marker holding all the markers.
+func EncodeFCPXML(w io.Writer, markers []Marker, opts *MarkerOptions) error {
+	o := opts.withDefaults()
+
+	seconds := func(frames int) string {
+		return fcpxmlTime(frames, o.FrameRate)
+	}
+
+	length := 1
+	var fm []fcpxmlMarker
+	for _, m := range markers {
+		in, out := o.span(m)
+		fm = append(fm, fcpxmlMarker{
+			Start:    seconds(in.Frames),
+			Duration: seconds(out.Sub(in)),
+			Value:    m.Name,
+			Note:     m.Comment,
+		})
+		if end := out.Sub(o.StartTimecode); end > length {
+			length = end
+		}
+	}
+
+	tcFormat := "NDF"
+	if o.FrameRate.DropFrame {
+		tcFormat = "DF"
+	}
+
+	doc := fcpxml{
+		Version: "1.9",
+		Resources: fcpxmlResource{Format: fcpxmlFormat{
+			ID:            "r1",
+			FrameDuration: seconds(1),
+			Width:         1920,
+			Height:        1080,
+		}},
+		Event: fcpxmlEvent{
+			Name: o.Title,
+			Project: fcpxmlProject{
+				Name: o.Title,
+				Sequence: fcpxmlSequence{
+					Format:   "r1",
+					Duration: seconds(length),
+					TCStart:  seconds(o.StartTimecode.Frames),
+					TCFormat: tcFormat,
+					Gap: fcpxmlGap{
+						Name:     "Gap",
+						Offset:   seconds(o.StartTimecode.Frames),
+						Start:    seconds(o.StartTimecode.Frames),
+						Duration: seconds(length),
+						Markers:  fm,
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header+"<!DOCTYPE fcpxml>\n"); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed encoding fcpxml %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// fcpxmlTime formats a frame count as a number of seconds in multiples of
+// the frame duration, as Final Cut Pro does.
+func fcpxmlTime(frames int, rate FrameRate) string {
+	n := int64(frames) * int64(rate.Den)
+	if n%int64(rate.Num) == 0 {
+		return strconv.FormatInt(n/int64(rate.Num), 10) + "s"
+	}
+	return fmt.Sprintf("%d/%ds", n, rate.Num)
+}
+
+// edlText removes line breaks which would end an EDL line.
+func edlText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,129 @@
+package revai
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testMarkerOptions = &MarkerOptions{
+	FrameRate:     FrameRate25,
+	StartTimecode: Timecode{Frames: 90000, Rate: FrameRate25},
+	Title:         "Interview",
+}
+
+func TestTranscript_Markers(t *testing.T) {
+	markers := testTranscript.Markers(nil)
+
+	assert.Equal(t, []Marker{
+		{Start: 500 * time.Millisecond, End: 2100 * time.Millisecond, Name: "Speaker 0", Comment: "Hello, my name is Jane."},
+		{Start: 2500 * time.Millisecond, End: 3400 * time.Millisecond, Name: "Speaker 1", Comment: "Nice to meet you."},
+	}, markers)
+}
+
+func TestTranscript_MarkersSpeakerNames(t *testing.T) {
+	markers := testTranscript.Markers(map[int]string{1: "Bob"})
+
+	if assert.Len(t, markers, 2) {
+		assert.Equal(t, "Speaker 0", markers[0].Name)
+		assert.Equal(t, "Bob", markers[1].Name)
+	}
+}
+
+func TestTranscript_SpanMarker(t *testing.T) {
+	marker := testTranscript.SpanMarker(time.Second, 2200*time.Millisecond, "Name")
+
+	assert.Equal(t, "my name is Jane.", marker.Comment)
+}
+
+func TestEncodeEDL(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeEDL(buf, testTranscript.Markers(nil), testMarkerOptions); err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "TITLE: Interview\nFCM: NON-DROP FRAME\n"))
+	assert.Contains(t, out, "\n001  AX       V     C        01:00:00:13 01:00:02:03 01:00:00:13 01:00:02:03\n")
+	assert.Contains(t, out, "\n* LOC: 01:00:00:13 BLUE    Speaker 0\n")
+	assert.Contains(t, out, "\n* COMMENT: Nice to meet you.\n")
+}
+
+func TestEncodeEDL_TooManyMarkers(t *testing.T) {
+	markers := make([]Marker, 1000)
+	assert.Error(t, EncodeEDL(new(bytes.Buffer), markers, nil))
+	assert.Error(t, EncodeResolveEDL(new(bytes.Buffer), markers, nil))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, EncodeEDL(buf, markers[:999], nil))
+	assert.Contains(t, buf.String(), "\n999  AX ")
+}
+
+func TestEncodeResolveEDL(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeResolveEDL(buf, testTranscript.Markers(nil), &MarkerOptions{FrameRate: FrameRate2997DF}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.Contains(t, out, "FCM: DROP FRAME\n")
+	assert.Contains(t, out, "\n001  001      V     C        00:00:00;15 00:00:00;16 00:00:00;15 00:00:00;16  \n")
+	assert.Contains(t, out, "\nHello, my name is Jane. |C:ResolveColorBlue |M:Speaker 0 |D:48\n")
+}
+
+func TestEncodePremiereMarkers(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodePremiereMarkers(buf, testTranscript.Markers(nil), testMarkerOptions); err != nil {
+		t.Error(err)
+		return
+	}
+
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Len(t, records, 3)
+	assert.Equal(t, []string{"Speaker 1", "Nice to meet you.", "01:00:02:13", "01:00:03:10", "00:00:00:22", "Comment"}, records[2])
+}
+
+func TestEncodeFCPXML(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeFCPXML(buf, testTranscript.Markers(nil), &MarkerOptions{StartTimecode: Timecode{Frames: 86314, Rate: FrameRate23976}}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	var doc fcpxml
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, "1001/24000s", doc.Resources.Format.FrameDuration)
+	seq := doc.Event.Project.Sequence
+	assert.Equal(t, "86400314/24000s", seq.TCStart)
+	assert.Equal(t, "NDF", seq.TCFormat)
+	if assert.Len(t, seq.Gap.Markers, 2) {
+		assert.Equal(t, fcpxmlMarker{
+			Start:    "86412326/24000s",
+			Duration: "38038/24000s",
+			Value:    "Speaker 0",
+			Note:     "Hello, my name is Jane.",
+		}, seq.Gap.Markers[0])
+	}
+}
+
+func TestFCPXMLTime(t *testing.T) {
+	assert.Equal(t, "0s", fcpxmlTime(0, FrameRate25))
+	assert.Equal(t, "3600s", fcpxmlTime(90000, FrameRate25))
+	assert.Equal(t, "1/25s", fcpxmlTime(1, FrameRate25))
+}
@@ -0,0 +1,253 @@
+package revai
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TagKind is the kind of a non-speech tag.
+type TagKind string
+
+const (
+	TagInaudible   TagKind = "inaudible"
+	TagCrosstalk   TagKind = "crosstalk"
+	TagLaughter    TagKind = "laughter"
+	TagAffirmative TagKind = "affirmative"
+	TagNegative    TagKind = "negative"
+	TagMusic       TagKind = "music"
+	TagNoise       TagKind = "noise"
+	TagApplause    TagKind = "applause"
+	TagOther       TagKind = "other"
+)
+
+// tagKinds maps the names of tags to their kind.
+var tagKinds = map[string]TagKind{
+	"inaudible": TagInaudible, "unintelligible": TagInaudible, "indiscernible": TagInaudible,
+	"crosstalk": TagCrosstalk, "cross talk": TagCrosstalk, "overlapping": TagCrosstalk,
+	"laugh": TagLaughter, "laughs": TagLaughter, "laughing": TagLaughter, "laughter": TagLaughter, "chuckles": TagLaughter,
+	"affirmative": TagAffirmative,
+	"negative":    TagNegative,
+	"music":       TagMusic,
+	"noise":       TagNoise, "background noise": TagNoise,
+	"applause": TagApplause, "clapping": TagApplause,
+}
+
+// nonSpeechTag matches the non-speech tags of a value, such as <laugh> or
+// [crosstalk].
+var nonSpeechTag = regexp.MustCompile(`<\s*([^<>\[\]]+?)\s*>|\[\s*([^<>\[\]]+?)\s*\]`)
+
+// markupTag matches the tags written in <> within the value of a word.
+// Brackets in words are left alone as they may be written by the caller,
+// such as the placeholders of Transcript.Redact.
+var markupTag = regexp.MustCompile(`<\s*([^<>\[\]]+?)\s*>`)
+
+// elementTag returns the pattern of the tags of an element.
+func elementTag(element Element) *regexp.Regexp {
+	if element.Type == ElementUnknown {
+		return nonSpeechTag
+	}
+	return markupTag
+}
+
+// NonSpeechEvent is a non-speech tag of a transcript such as <inaudible> or
+// <laugh>, with the time of the element it was found in.
+type NonSpeechEvent struct {
+	Kind TagKind `json:"kind"`
+
+	// Tag is the tag as written without its brackets.
+	Tag string `json:"tag"`
+
+	Speaker   int     `json:"speaker"`
+	Monologue int     `json:"monologue"`
+	Element   int     `json:"element"`
+	Ts        float64 `json:"ts"`
+	EndTs     float64 `json:"end_ts"`
+}
+
+// TimeRange returns the time range of the event.
+func (e NonSpeechEvent) TimeRange() TimeRange {
+	return TimeRange{Start: secondsToDuration(e.Ts), End: secondsToDuration(e.EndTs)}
+}
+
+// ParseTag returns the kind and name of a bracketed tag such as <laugh> or
+// [Crosstalk]. Tags of unknown names are of kind TagOther.
+func ParseTag(s string) (TagKind, string, bool) {
+	loc := nonSpeechTag.FindStringSubmatchIndex(s)
+	if loc == nil || loc[0] != 0 || loc[1] != len(s) {
+		return "", "", false
+	}
+	var name string
+	if loc[2] >= 0 {
+		name = s[loc[2]:loc[3]]
+	} else {
+		name = s[loc[4]:loc[5]]
+	}
+	return tagKind(name), name, true
+}
+
+func tagKind(name string) TagKind {
+	if kind, ok := tagKinds[strings.ToLower(strings.Join(strings.Fields(name), " "))]; ok {
+		return kind
+	}
+	return TagOther
+}
+
+// NonSpeechEvents returns the non-speech tags of the transcript in order,
+// whether they are elements of their own or written in <> within the value of
+// a word.
+func (t *Transcript) NonSpeechEvents() []NonSpeechEvent {
+	var events []NonSpeechEvent
+	for i, monologue := range t.Monologues {
+		for j, element := range monologue.Elements {
+			for _, name := range elementTags(element) {
+				events = append(events, NonSpeechEvent{
+					Kind:      tagKind(name),
+					Tag:       name,
+					Speaker:   monologue.Speaker,
+					Monologue: i,
+					Element:   j,
+					Ts:        element.Ts,
+					EndTs:     element.EndTs,
+				})
+			}
+		}
+	}
+	return events
+}
+
+// elementTags returns the names of the tags in the value of an element.
+func elementTags(element Element) []string {
+	var names []string
+	for _, m := range elementTag(element).FindAllStringSubmatch(element.Value, -1) {
+		name := m[1]
+		if name == "" && len(m) > 2 {
+			name = m[2]
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// NonSpeechMode is how Transcript.WithNonSpeech renders non-speech tags.
+type NonSpeechMode int
+
+const (
+	// NonSpeechInclude keeps tags as written.
+	NonSpeechInclude NonSpeechMode = iota
+	// NonSpeechExclude removes tags.
+	NonSpeechExclude
+	// NonSpeechAnnotate replaces tags with annotations such as [laughter].
+	NonSpeechAnnotate
+)
+
+// NonSpeechOptions specifies how non-speech tags are rendered.
+type NonSpeechOptions struct {
+	Mode NonSpeechMode
+
+	// Kinds are the kinds of tags the mode applies to, other tags are kept as
+	// written. It defaults to all kinds.
+	Kinds []TagKind
+
+	// Annotation returns the annotation of an event for NonSpeechAnnotate. It
+	// defaults to the kind in brackets, or the tag for TagOther, such as
+	// [laughter].
+	Annotation func(NonSpeechEvent) string
+}
+
+// WithNonSpeech returns a copy of the transcript with its non-speech tags
+// included, excluded or annotated. Included and annotated tags become text
+// elements so every text and caption rendering of the copy shows them, and
+// monologues left without elements are left out.
+func (t *Transcript) WithNonSpeech(opts *NonSpeechOptions) *Transcript {
+	if opts == nil {
+		opts = &NonSpeechOptions{}
+	}
+
+	applies := func(kind TagKind) bool {
+		if opts.Kinds == nil {
+			return true
+		}
+		for _, k := range opts.Kinds {
+			if k == kind {
+				return true
+			}
+		}
+		return false
+	}
+	annotation := opts.Annotation
+	if annotation == nil {
+		annotation = func(e NonSpeechEvent) string {
+			if e.Kind == TagOther {
+				return "[" + strings.ToLower(e.Tag) + "]"
+			}
+			return "[" + string(e.Kind) + "]"
+		}
+	}
+
+	rendered := &Transcript{}
+	for i, monologue := range t.Monologues {
+		var (
+			elements []Element
+			removed  bool
+		)
+		for j, element := range monologue.Elements {
+			tagged := false
+			value := elementTag(element).ReplaceAllStringFunc(element.Value, func(tag string) string {
+				kind, name, _ := ParseTag(tag)
+				if !applies(kind) {
+					return tag
+				}
+				tagged = true
+
+				switch opts.Mode {
+				case NonSpeechExclude:
+					return ""
+				case NonSpeechAnnotate:
+					return annotation(NonSpeechEvent{
+						Kind:      kind,
+						Tag:       name,
+						Speaker:   monologue.Speaker,
+						Monologue: i,
+						Element:   j,
+						Ts:        element.Ts,
+						EndTs:     element.EndTs,
+					})
+				}
+				return tag
+			})
+			if tagged {
+				element.Type = ElementText
+				element.Value = strings.Join(strings.Fields(value), " ")
+				if element.Value == "" {
+					removed = true
+					continue
+				}
+			}
+
+			// whitespace left around removed tags is collapsed.
+			n := len(elements)
+			switch {
+			case removed && whitespace(element) && (n == 0 || whitespace(elements[n-1])):
+				continue
+			case removed && element.Type == ElementPunct && n > 0 && whitespace(elements[n-1]):
+				elements = elements[:n-1]
+			}
+			removed = false
+			elements = append(elements, element)
+		}
+		for n := len(elements); removed && n > 0 && whitespace(elements[n-1]); n-- {
+			elements = elements[:n-1]
+		}
+
+		if len(elements) > 0 {
+			rendered.Monologues = append(rendered.Monologues, Monologue{Speaker: monologue.Speaker, Elements: elements})
+		}
+	}
+
+	return rendered
+}
+
+// whitespace reports whether an element is punctuation of only whitespace.
+func whitespace(element Element) bool {
+	return element.Type == ElementPunct && strings.TrimSpace(element.Value) == ""
+}
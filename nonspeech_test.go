@@ -0,0 +1,128 @@
+package revai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var nonSpeechTranscript = &Transcript{Monologues: []Monologue{
+	{Speaker: 0, Elements: []Element{
+		{Type: ElementText, Value: "So", Ts: 0, EndTs: 0.3},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementUnknown, Value: "<laugh>", Ts: 0.3, EndTs: 1.0},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementText, Value: "anyway", Ts: 1.0, EndTs: 1.4},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementUnknown, Value: "<inaudible>", Ts: 1.4, EndTs: 2.0},
+		{Type: ElementPunct, Value: "."},
+	}},
+	{Speaker: 1, Elements: []Element{
+		{Type: ElementUnknown, Value: "<affirmative>", Ts: 2.5, EndTs: 2.8},
+	}},
+	{Speaker: 0, Elements: []Element{
+		{Type: ElementText, Value: "right <Cross Talk>", Ts: 3.0, EndTs: 3.5},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementUnknown, Value: "<door slams>", Ts: 3.5, EndTs: 4.0},
+	}},
+}}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		s    string
+		kind TagKind
+		name string
+		ok   bool
+	}{
+		{"<laugh>", TagLaughter, "laugh", true},
+		{"[Laughter]", TagLaughter, "Laughter", true},
+		{"< inaudible >", TagInaudible, "inaudible", true},
+		{"[cross  talk]", TagCrosstalk, "cross  talk", true},
+		{"<door slams>", TagOther, "door slams", true},
+		{"hello", "", "", false},
+		{"<laugh> yes", "", "", false},
+	}
+
+	for _, tt := range tests {
+		kind, name, ok := ParseTag(tt.s)
+		assert.Equal(t, tt.kind, kind, tt.s)
+		assert.Equal(t, tt.name, name, tt.s)
+		assert.Equal(t, tt.ok, ok, tt.s)
+	}
+}
+
+func TestTranscript_NonSpeechEvents(t *testing.T) {
+	events := nonSpeechTranscript.NonSpeechEvents()
+
+	if assert.Len(t, events, 5) {
+		assert.Equal(t, NonSpeechEvent{Kind: TagLaughter, Tag: "laugh", Speaker: 0, Monologue: 0, Element: 2, Ts: 0.3, EndTs: 1.0}, events[0])
+		assert.Equal(t, TagInaudible, events[1].Kind)
+		assert.Equal(t, TagAffirmative, events[2].Kind)
+		assert.Equal(t, 1, events[2].Speaker)
+
+		// tags written within the value of a word.
+		assert.Equal(t, NonSpeechEvent{Kind: TagCrosstalk, Tag: "Cross Talk", Speaker: 0, Monologue: 2, Element: 0, Ts: 3.0, EndTs: 3.5}, events[3])
+		assert.Equal(t, TagOther, events[4].Kind)
+		assert.Equal(t, TimeRange{Start: 3500000000, End: 4000000000}, events[4].TimeRange())
+	}
+}
+
+func TestTranscript_WithNonSpeech(t *testing.T) {
+	included := nonSpeechTranscript.WithNonSpeech(nil)
+	assert.Equal(t, "So <laugh> anyway <inaudible>.\n<affirmative>\nright <Cross Talk> <door slams>", transcriptText(included))
+	assert.Equal(t, ElementText, included.Monologues[0].Elements[2].Type)
+	assert.Equal(t, ElementUnknown, nonSpeechTranscript.Monologues[0].Elements[2].Type)
+
+	excluded := nonSpeechTranscript.WithNonSpeech(&NonSpeechOptions{Mode: NonSpeechExclude})
+	assert.Equal(t, "So anyway.\nright", transcriptText(excluded))
+	assert.Equal(t, []Element{
+		{Type: ElementText, Value: "So", Ts: 0, EndTs: 0.3},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementText, Value: "anyway", Ts: 1.0, EndTs: 1.4},
+		{Type: ElementPunct, Value: "."},
+	}, excluded.Monologues[0].Elements)
+
+	annotated := nonSpeechTranscript.WithNonSpeech(&NonSpeechOptions{Mode: NonSpeechAnnotate})
+	assert.Equal(t, "So [laughter] anyway [inaudible].\n[affirmative]\nright [crosstalk] [door slams]", transcriptText(annotated))
+
+	// only some kinds with a custom annotation.
+	custom := nonSpeechTranscript.WithNonSpeech(&NonSpeechOptions{
+		Mode:       NonSpeechAnnotate,
+		Kinds:      []TagKind{TagLaughter},
+		Annotation: func(e NonSpeechEvent) string { return "(LAUGHS)" },
+	})
+	assert.Equal(t, "So (LAUGHS) anyway <inaudible>.\n<affirmative>\nright <Cross Talk> <door slams>", transcriptText(custom))
+
+	// annotations are shown by caption renderings.
+	cues := annotated.Cues(nil)
+	if assert.NotEmpty(t, cues) {
+		assert.Equal(t, "So [laughter] anyway [inaudible].", strings.Join(cues[0].Lines, " "))
+	}
+}
+
+func TestTranscript_WithNonSpeech_Redacted(t *testing.T) {
+	tr := &Transcript{Monologues: []Monologue{{Speaker: 0, Elements: []Element{
+		{Type: ElementText, Value: "call", Ts: 0, EndTs: 0.3},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementText, Value: "555-0100", Ts: 0.3, EndTs: 1.0},
+		{Type: ElementPunct, Value: " "},
+		{Type: ElementUnknown, Value: "<laugh>", Ts: 1.0, EndTs: 1.5},
+	}}}}
+	redacted, _ := tr.Redact(nil)
+	if !assert.Contains(t, transcriptText(redacted), "[PHONE]") {
+		return
+	}
+
+	// placeholders are words rather than tags.
+	assert.Len(t, redacted.NonSpeechEvents(), 1)
+	assert.Equal(t, "call [PHONE]", transcriptText(redacted.WithNonSpeech(&NonSpeechOptions{Mode: NonSpeechExclude})))
+
+	annotated := redacted.WithNonSpeech(&NonSpeechOptions{Mode: NonSpeechAnnotate})
+	assert.Equal(t, "call [PHONE] [laughter]", transcriptText(annotated))
+
+	// annotations are not parsed again.
+	again := annotated.WithNonSpeech(&NonSpeechOptions{Mode: NonSpeechAnnotate, Annotation: func(NonSpeechEvent) string { return "(LAUGHS)" }})
+	assert.Equal(t, "call [PHONE] [laughter]", transcriptText(again))
+	assert.Empty(t, annotated.NonSpeechEvents())
+}
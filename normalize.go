@@ -0,0 +1,494 @@
+package revai
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HyphenMode is how hyphenated words are normalized.
+type HyphenMode int
+
+const (
+	// HyphenKeep leaves hyphenated words as they are.
+	HyphenKeep HyphenMode = iota
+	// HyphenSplit splits hyphenated words into separate words.
+	HyphenSplit
+	// HyphenJoin removes hyphens, joining the parts into a single word.
+	HyphenJoin
+)
+
+// NumberMode is how numbers are normalized.
+type NumberMode int
+
+const (
+	// NumbersKeep leaves numbers as they are written.
+	NumbersKeep NumberMode = iota
+	// NumbersToWords writes numbers such as 42 or 3.5% out in words.
+	NumbersToWords
+	// WordsToNumbers writes numbers said in words, such as
+	// "twenty five", as digits.
+	WordsToNumbers
+)
+
+// NormalizeOptions specifies the steps of the normalization pipeline.
+// Steps run in the order of the fields.
+type NormalizeOptions struct {
+	// RemoveTags removes bracketed tags such as <inaudible> or [laughter]
+	// and unknown elements.
+	RemoveTags bool
+
+	Lowercase bool
+
+	// ExpandContractions expands contractions such as "don't" to "do not".
+	ExpandContractions bool
+
+	Hyphens HyphenMode
+
+	Numbers NumberMode
+
+	// StripPunctuation removes punctuation from words, except apostrophes
+	// and hyphens within words.
+	StripPunctuation bool
+
+	// RemoveFillers removes filler words such as "um" and "uh".
+	RemoveFillers bool
+
+	// Fillers overrides the filler words removed by RemoveFillers.
+	Fillers []string
+}
+
+// StandardNormalization lowercases words, expands contractions, splits
+// hyphenated words and removes punctuation, fillers and tags.
+var StandardNormalization = NormalizeOptions{
+	RemoveTags:         true,
+	Lowercase:          true,
+	ExpandContractions: true,
+	Hyphens:            HyphenSplit,
+	StripPunctuation:   true,
+	RemoveFillers:      true,
+}
+
+// defaultFillers are the filler words removed by RemoveFillers.
+var defaultFillers = []string{"um", "uh", "er", "erm", "ah", "eh", "hmm", "mm", "mhm", "uhm"}
+
+var contractions = map[string]string{
+	"ain't": "am not", "aren't": "are not", "can't": "can not", "couldn't": "could not",
+	"didn't": "did not", "doesn't": "does not", "don't": "do not", "hadn't": "had not",
+	"hasn't": "has not", "haven't": "have not", "isn't": "is not", "mightn't": "might not",
+	"mustn't": "must not", "needn't": "need not", "shan't": "shall not", "shouldn't": "should not",
+	"wasn't": "was not", "weren't": "were not", "won't": "will not", "wouldn't": "would not",
+	"i'm": "i am", "let's": "let us", "it's": "it is", "that's": "that is", "what's": "what is",
+	"there's": "there is", "here's": "here is", "he's": "he is", "she's": "she is",
+	"who's": "who is", "where's": "where is", "how's": "how is", "y'all": "you all",
+	"gonna": "going to", "wanna": "want to", "gotta": "got to",
+}
+
+// contractionSuffixes are expanded on words not listed in contractions.
+var contractionSuffixes = []struct{ suffix, expansion string }{
+	{"n't", "not"}, {"'re", "are"}, {"'ve", "have"}, {"'ll", "will"}, {"'d", "would"},
+}
+
+var tagPattern = regexp.MustCompile(`^[<\[(].*[>\])]$`)
+
+// NormalizedWord is a word of the normalized view of a transcript along with
+// the elements it was made from. Words made from several elements, such as
+// numbers said in words, span FirstElement to LastElement.
+type NormalizedWord struct {
+	Text         string  `json:"text"`
+	Speaker      int     `json:"speaker"`
+	Monologue    int     `json:"monologue"`
+	FirstElement int     `json:"first_element"`
+	LastElement  int     `json:"last_element"`
+	Ts           float64 `json:"ts"`
+	EndTs        float64 `json:"end_ts"`
+}
+
+// Normalize returns the normalized words of the transcript. Punctuation
+// elements are never words. A nil opts uses StandardNormalization.
+func (t *Transcript) Normalize(opts *NormalizeOptions) []NormalizedWord {
+	var words []NormalizedWord
+	for i, monologue := range t.Monologues {
+		for j, element := range monologue.Elements {
+			if element.Type == ElementPunct {
+				continue
+			}
+			text := element.Value
+			if element.Type != ElementText && !tagPattern.MatchString(strings.TrimSpace(text)) {
+				text = "<" + strings.TrimSpace(text) + ">"
+			}
+			words = append(words, NormalizedWord{
+				Text:         text,
+				Speaker:      monologue.Speaker,
+				Monologue:    i,
+				FirstElement: j,
+				LastElement:  j,
+				Ts:           element.Ts,
+				EndTs:        element.EndTs,
+			})
+		}
+	}
+	return normalizeWords(words, opts)
+}
+
+// NormalizeText returns the normalized words of s. A nil opts uses
+// StandardNormalization.
+func NormalizeText(s string, opts *NormalizeOptions) []string {
+	var words []NormalizedWord
+	for i, field := range strings.Fields(s) {
+		words = append(words, NormalizedWord{Text: field, FirstElement: i, LastElement: i})
+	}
+
+	// bracketed tags of several words are joined so they are removed together.
+	for i := 0; i < len(words); i++ {
+		if c := words[i].Text[0]; c != '<' && c != '[' && c != '(' {
+			continue
+		}
+		for j := i; j < len(words); j++ {
+			joined := joinNormalizedWords(words[i : j+1])
+			if tagPattern.MatchString(joined.Text) {
+				words = append(words[:i], append([]NormalizedWord{joined}, words[j+1:]...)...)
+				break
+			}
+		}
+	}
+
+	normalized := normalizeWords(words, opts)
+
+	texts := make([]string, len(normalized))
+	for i, w := range normalized {
+		texts[i] = w.Text
+	}
+	return texts
+}
+
+func joinNormalizedWords(words []NormalizedWord) NormalizedWord {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.Text
+	}
+	joined := words[0]
+	joined.Text = strings.Join(texts, " ")
+	joined.LastElement = words[len(words)-1].LastElement
+	joined.EndTs = words[len(words)-1].EndTs
+	return joined
+}
+
+// normalizeWords runs the normalization pipeline over words.
+func normalizeWords(words []NormalizedWord, opts *NormalizeOptions) []NormalizedWord {
+	if opts == nil {
+		opts = &StandardNormalization
+	}
+
+	// each step maps a word to the words it is replaced by.
+	each := func(words []NormalizedWord, step func(w NormalizedWord) []string) []NormalizedWord {
+		var out []NormalizedWord
+		for _, w := range words {
+			for _, text := range step(w) {
+				nw := w
+				nw.Text = text
+				out = append(out, nw)
+			}
+		}
+		return out
+	}
+
+	if opts.RemoveTags {
+		words = each(words, func(w NormalizedWord) []string {
+			if tagPattern.MatchString(w.Text) {
+				return nil
+			}
+			return []string{w.Text}
+		})
+	}
+
+	// tags are kept whole from here on.
+	step := func(words []NormalizedWord, f func(string) []string) []NormalizedWord {
+		return each(words, func(w NormalizedWord) []string {
+			if tagPattern.MatchString(w.Text) {
+				return []string{w.Text}
+			}
+			return f(w.Text)
+		})
+	}
+
+	words = step(words, strings.Fields)
+
+	if opts.Lowercase {
+		words = step(words, func(s string) []string { return []string{strings.ToLower(s)} })
+	}
+
+	if opts.ExpandContractions {
+		words = step(words, expandContraction)
+	}
+
+	switch opts.Hyphens {
+	case HyphenSplit:
+		words = step(words, func(s string) []string {
+			return strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '‐' })
+		})
+	case HyphenJoin:
+		words = step(words, func(s string) []string {
+			return []string{strings.NewReplacer("-", "", "‐", "").Replace(s)}
+		})
+	}
+
+	switch opts.Numbers {
+	case NumbersToWords:
+		words = step(words, func(s string) []string {
+			trimmed := strings.TrimRight(s, ".,;:!?")
+			if number, ok := spokenNumber(trimmed); ok {
+				if trimmed != s && !opts.StripPunctuation {
+					number[len(number)-1] += s[len(trimmed):]
+				}
+				return number
+			}
+			return []string{s}
+		})
+	case WordsToNumbers:
+		words = wordsToNumbers(words)
+	}
+
+	if opts.StripPunctuation {
+		words = step(words, func(s string) []string {
+			if s = stripPunctuation(s); s == "" {
+				return nil
+			}
+			return []string{s}
+		})
+	}
+
+	if opts.RemoveFillers {
+		fillers := map[string]bool{}
+		list := opts.Fillers
+		if list == nil {
+			list = defaultFillers
+		}
+		for _, f := range list {
+			fillers[strings.ToLower(f)] = true
+		}
+
+		words = step(words, func(s string) []string {
+			if fillers[strings.ToLower(stripPunctuation(s))] {
+				return nil
+			}
+			return []string{s}
+		})
+	}
+
+	return words
+}
+
+// expandContraction returns the words of s with a contraction expanded,
+// keeping the case of its first letter.
+func expandContraction(s string) []string {
+	word := strings.ReplaceAll(s, "’", "'")
+
+	// offsets are taken from word rather than its lowercase, which may be of
+	// a different length.
+	trimmed := strings.TrimRightFunc(word, func(r rune) bool { return unicode.IsPunct(r) && r != '\'' })
+	punct := word[len(trimmed):]
+
+	expansion, ok := contractions[strings.ToLower(trimmed)]
+	if !ok {
+		for _, c := range contractionSuffixes {
+			n := len(trimmed) - len(c.suffix)
+			if n > 0 && strings.ToLower(trimmed[n:]) == c.suffix {
+				expansion = trimmed[:n] + " " + c.expansion
+				ok = true
+				break
+			}
+		}
+	}
+	if !ok {
+		return []string{s}
+	}
+
+	words := strings.Fields(expansion + punct)
+	if r, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(r) {
+		first, size := utf8.DecodeRuneInString(words[0])
+		words[0] = string(unicode.ToUpper(first)) + words[0][size:]
+	}
+	return words
+}
+
+// stripPunctuation removes punctuation from s, except apostrophes and hyphens
+// between letters such as in "don't" and "well-known".
+func stripPunctuation(s string) string {
+	runes := []rune(s)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsPunct(r) && !unicode.IsSymbol(r) {
+			b.WriteRune(r)
+			continue
+		}
+		inWord := i > 0 && i < len(runes)-1 && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+		if inWord && (r == '\'' || r == '’' || r == '-') {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+var (
+	numberWordValues = map[string]int64{}
+	numberScaleWords = map[string]int64{}
+)
+
+func init() {
+	for i, w := range numberOnes {
+		numberWordValues[w] = int64(i)
+	}
+	for i, w := range numberTens {
+		if w != "" {
+			numberWordValues[w] = int64(i * 10)
+		}
+	}
+	for _, s := range numberScales {
+		numberScaleWords[s.name] = s.value
+	}
+}
+
+// wordsToNumbers replaces runs of number words with digits.
+func wordsToNumbers(words []NormalizedWord) []NormalizedWord {
+	var out []NormalizedWord
+	for i := 0; i < len(words); {
+		value, n := parseNumberWords(words[i:])
+		if n == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+		w := joinNormalizedWords(words[i : i+n])
+		w.Text = value
+		out = append(out, w)
+		i += n
+	}
+	return out
+}
+
+// parseNumberWords parses the number said at the start of words and returns
+// it in digits along with the number of words it is made of. Punctuation
+// after the last word is kept.
+func parseNumberWords(words []NormalizedWord) (string, int) {
+	const (
+		none = iota
+		ones
+		tens
+		hundred
+		scale
+	)
+
+	var (
+		total, current int64
+		last           = none
+		lastScale      int64
+		n              int
+		negative       bool
+		punct          string
+	)
+
+	word := func(i int) (string, string) {
+		trimmed := strings.TrimRightFunc(words[i].Text, unicode.IsPunct)
+		return strings.ToLower(trimmed), words[i].Text[len(trimmed):]
+	}
+
+	if len(words) > 1 {
+		w, p := word(0)
+		next, _ := word(1)
+		if _, ok := numberWordValues[next]; ok && p == "" && (w == "minus" || w == "negative") {
+			negative = true
+			n = 1
+		}
+	}
+
+	start := n
+	for ; n < len(words) && punct == ""; n++ {
+		w, p := word(n)
+
+		if v, ok := numberWordValues[w]; ok {
+			switch {
+			case v < 10 && (last == none || last == tens || last == hundred || last == scale):
+			case v >= 10 && v < 20 && (last == none || last == hundred || last == scale):
+			case v >= 20 && (last == none || last == hundred || last == scale):
+			default:
+				return numberResult(total+current, negative, "", words, start, n, punct)
+			}
+			current += v
+			if v >= 20 {
+				last = tens
+			} else {
+				last = ones
+			}
+			punct = p
+			continue
+		}
+
+		if w == "hundred" && (last == ones || last == tens) && current < 100 {
+			current *= 100
+			last = hundred
+			punct = p
+			continue
+		}
+
+		if v, ok := numberScaleWords[w]; ok && last != none && last != scale && current > 0 && (lastScale == 0 || v < lastScale) {
+			total += current * v
+			current = 0
+			last = scale
+			lastScale = v
+			punct = p
+			continue
+		}
+
+		if w == "and" && p == "" && (last == hundred || last == scale) && n+1 < len(words) {
+			if next, _ := word(n + 1); numberWordValues[next] > 0 {
+				continue
+			}
+		}
+
+		if w == "point" && p == "" && last != none {
+			var digits strings.Builder
+			k := n + 1
+			for ; k < len(words); k++ {
+				d, dp := word(k)
+				v, ok := numberWordValues[d]
+				if !ok || v > 9 {
+					break
+				}
+				digits.WriteString(strconv.FormatInt(v, 10))
+				if dp != "" {
+					punct = dp
+					k++
+					break
+				}
+			}
+			if digits.Len() > 0 {
+				return numberResult(total+current, negative, digits.String(), words, start, k, punct)
+			}
+		}
+
+		break
+	}
+
+	return numberResult(total+current, negative, "", words, start, n, punct)
+}
+
+func numberResult(value int64, negative bool, fraction string, words []NormalizedWord, start, end int, punct string) (string, int) {
+	if end == start {
+		return "", 0
+	}
+
+	s := strconv.FormatInt(value, 10)
+	if fraction != "" {
+		s += "." + fraction
+	}
+	if negative {
+		s = "-" + s
+	}
+
+	return s + punct, end
+}
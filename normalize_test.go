@@ -0,0 +1,89 @@
+package revai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func normalizedTexts(words []NormalizedWord) []string {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.Text
+	}
+	return texts
+}
+
+func TestTranscript_Normalize(t *testing.T) {
+	transcript := makeTestTranscript("Um, I don't know. It's a well-known fact")
+	transcript.Monologues[0].Elements = append(transcript.Monologues[0].Elements,
+		Element{Type: "punct", Value: " "},
+		Element{Type: "unknown", Value: "<inaudible>", Ts: 2.4, EndTs: 2.7},
+	)
+
+	words := transcript.Normalize(nil)
+
+	assert.Equal(t, []string{"i", "do", "not", "know", "it", "is", "a", "well", "known", "fact"}, normalizedTexts(words))
+
+	// expanded words keep the element they came from.
+	assert.Equal(t, "not", words[2].Text)
+	assert.Equal(t, 5, words[2].FirstElement)
+	assert.InDelta(t, 0.6, words[2].Ts, 1e-9)
+	assert.Equal(t, words[7].FirstElement, words[8].FirstElement)
+}
+
+func TestTranscript_Normalize_Options(t *testing.T) {
+	transcript := makeTestTranscript("Um, I don't know.")
+
+	words := transcript.Normalize(&NormalizeOptions{})
+	assert.Equal(t, []string{"Um", "I", "don't", "know"}, normalizedTexts(words))
+
+	words = transcript.Normalize(&NormalizeOptions{ExpandContractions: true})
+	assert.Equal(t, []string{"Um", "I", "do", "not", "know"}, normalizedTexts(words))
+
+	words = transcript.Normalize(&NormalizeOptions{RemoveFillers: true, Fillers: []string{"know"}})
+	assert.Equal(t, []string{"Um", "I", "don't"}, normalizedTexts(words))
+}
+
+func TestTranscript_Normalize_WordsToNumbers(t *testing.T) {
+	transcript := makeTestTranscript("I owe you twenty-five dollars and three hundred and four cents, not one.")
+
+	words := transcript.Normalize(&NormalizeOptions{Lowercase: true, Hyphens: HyphenSplit, Numbers: WordsToNumbers, StripPunctuation: true})
+
+	assert.Equal(t, []string{"i", "owe", "you", "25", "dollars", "and", "304", "cents", "not", "1"}, normalizedTexts(words))
+
+	// numbers span the elements they were said in.
+	assert.Equal(t, 12, words[6].FirstElement)
+	assert.Equal(t, 18, words[6].LastElement)
+	assert.InDelta(t, 1.8, words[6].Ts, 1e-9)
+	assert.InDelta(t, 3.0, words[6].EndTs, 1e-9)
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts *NormalizeOptions
+		want []string
+	}{
+		{"standard", "Hello [background noise] there, <laughs> we're OK", nil, []string{"hello", "there", "we", "are", "ok"}},
+		{"keep tags", "[background noise] Hi", &NormalizeOptions{Lowercase: true}, []string{"[background noise]", "hi"}},
+		{"numbers to words", "I paid 1,200 for 3.5%.", &NormalizeOptions{Numbers: NumbersToWords, StripPunctuation: true}, []string{"I", "paid", "one", "thousand", "two", "hundred", "for", "three", "point", "five", "percent"}},
+		{"words to numbers", "two million three thousand and one", &NormalizeOptions{Numbers: WordsToNumbers}, []string{"2003001"}},
+		{"decimals", "minus one point two five", &NormalizeOptions{Numbers: WordsToNumbers}, []string{"-1.25"}},
+		{"digit strings", "four one one", &NormalizeOptions{Numbers: WordsToNumbers}, []string{"4", "1", "1"}},
+		{"punctuation after number", "nineteen ninety, ok", &NormalizeOptions{Numbers: WordsToNumbers}, []string{"19", "90,", "ok"}},
+		{"hyphen join", "e-mail me", &NormalizeOptions{Hyphens: HyphenJoin}, []string{"email", "me"}},
+		{"capitalized contraction", "Can't stop", &NormalizeOptions{ExpandContractions: true}, []string{"Can", "not", "stop"}},
+		{"curly apostrophe", "they’ll", &NormalizeOptions{ExpandContractions: true}, []string{"they", "will"}},
+		// the lowercase of these words is longer in bytes.
+		{"lowercase length contraction", "Ⱥ is İ'll.", &NormalizeOptions{ExpandContractions: true}, []string{"Ⱥ", "is", "İ", "will."}},
+		{"lowercase length numbers", "Ⱥ two İ", &NormalizeOptions{Numbers: WordsToNumbers}, []string{"Ⱥ", "2", "İ"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeText(tt.text, tt.opts))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package revai
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	numberOnes = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen",
+		"seventeen", "eighteen", "nineteen",
+	}
+	numberTens = []string{
+		"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety",
+	}
+	numberScales = []struct {
+		value int64
+		name  string
+	}{
+		{1000000000000, "trillion"},
+		{1000000000, "billion"},
+		{1000000, "million"},
+		{1000, "thousand"},
+	}
+)
+
+// numberWords returns n written out in English words, for example
+// "one thousand two hundred thirty four" for 1234. n must not be
+// math.MinInt64, which has no positive counterpart.
+func numberWords(n int64) []string {
+	if n < 0 {
+		return append([]string{"minus"}, numberWords(-n)...)
+	}
+	if n < 20 {
+		return []string{numberOnes[n]}
+	}
+
+	var words []string
+	for _, scale := range numberScales {
+		if n >= scale.value {
+			words = append(words, numberWords(n/scale.value)...)
+			words = append(words, scale.name)
+			n %= scale.value
+			if n == 0 {
+				return words
+			}
+		}
+	}
+
+	if n >= 100 {
+		words = append(words, numberOnes[n/100], "hundred")
+		n %= 100
+		if n == 0 {
+			return words
+		}
+	}
+
+	if n >= 20 {
+		words = append(words, numberTens[n/10])
+		n %= 10
+		if n == 0 {
+			return words
+		}
+	}
+
+	return append(words, numberOnes[n])
+}
+
+// spokenNumber returns the words of a written number such as 42, 1,000, 3.5,
+// -7 or 10%, and false when s is not a number.
+func spokenNumber(s string) ([]string, bool) {
+	var suffix []string
+	if strings.HasSuffix(s, "%") {
+		s = strings.TrimSuffix(s, "%")
+		suffix = []string{"percent"}
+	}
+
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+		if fraction == "" {
+			return nil, false
+		}
+	}
+
+	// thousands separators must group digits in threes.
+	if strings.Contains(integer, ",") {
+		groups := strings.Split(strings.TrimPrefix(integer, "-"), ",")
+		for i, g := range groups {
+			if len(g) != 3 && (i > 0 || len(g) == 0 || len(g) > 3) {
+				return nil, false
+			}
+		}
+		integer = strings.ReplaceAll(integer, ",", "")
+	}
+
+	// numbers beyond ±math.MaxInt64 are left unconverted.
+	n, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || n == math.MinInt64 || integer == "" || strings.HasPrefix(integer, "+") {
+		return nil, false
+	}
+
+	words := numberWords(n)
+	if fraction != "" {
+		words = append(words, "point")
+		for _, d := range fraction {
+			if d < '0' || d > '9' {
+				return nil, false
+			}
+			words = append(words, numberOnes[d-'0'])
+		}
+	}
+
+	return append(words, suffix...), true
+}
@@ -0,0 +1,381 @@
+package revai
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PIIType is the type of personal information a detector finds.
+type PIIType string
+
+const (
+	PIICreditCard PIIType = "CREDIT_CARD"
+	PIIPhone      PIIType = "PHONE"
+	PIIEmail      PIIType = "EMAIL"
+	PIISSN        PIIType = "SSN"
+)
+
+// PIIWord is a word of a monologue given to detectors.
+type PIIWord struct {
+	Text  string
+	Ts    float64
+	EndTs float64
+}
+
+// PIIMatch is personal information found in the words First to Last of a
+// monologue.
+type PIIMatch struct {
+	Type  PIIType
+	First int
+	Last  int
+}
+
+// PIIDetector finds personal information in the words of a monologue.
+type PIIDetector interface {
+	Detect(words []PIIWord) []PIIMatch
+}
+
+// PIIDetectorFunc is a function used as a PIIDetector.
+type PIIDetectorFunc func(words []PIIWord) []PIIMatch
+
+// Detect calls f(words).
+func (f PIIDetectorFunc) Detect(words []PIIWord) []PIIMatch {
+	return f(words)
+}
+
+var (
+	// CreditCardDetector finds runs of 13 to 19 digits passing the Luhn
+	// check, written or said as words.
+	CreditCardDetector PIIDetector = digitDetector(PIICreditCard, func(digits string) bool {
+		return len(digits) >= 13 && len(digits) <= 19 && luhn(digits)
+	})
+
+	// PhoneDetector finds runs of 7 or 10 digits, or 11 digits starting with
+	// a 1, written or said as words.
+	PhoneDetector PIIDetector = digitDetector(PIIPhone, func(digits string) bool {
+		return len(digits) == 7 || len(digits) == 10 || (len(digits) == 11 && digits[0] == '1')
+	})
+
+	// SSNDetector finds runs of 9 digits, written or said as words.
+	SSNDetector PIIDetector = digitDetector(PIISSN, func(digits string) bool {
+		return len(digits) == 9
+	})
+
+	// EmailDetector finds written email addresses and addresses said as
+	// words such as "jane dot doe at example dot com".
+	EmailDetector PIIDetector = PIIDetectorFunc(detectEmails)
+
+	// DefaultPIIDetectors are the detectors used by Redact by default.
+	DefaultPIIDetectors = []PIIDetector{CreditCardDetector, SSNDetector, PhoneDetector, EmailDetector}
+)
+
+// RegexpDetector returns a detector matching re against the words of a
+// monologue joined by single spaces. Matches cover the words they overlap.
+func RegexpDetector(typ PIIType, re *regexp.Regexp) PIIDetector {
+	return PIIDetectorFunc(func(words []PIIWord) []PIIMatch {
+		var (
+			b      strings.Builder
+			starts = make([]int, len(words))
+		)
+		for i, w := range words {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			starts[i] = b.Len()
+			b.WriteString(w.Text)
+		}
+
+		// word returns the index of the word holding byte offset.
+		word := func(offset int) int {
+			return sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+		}
+
+		var matches []PIIMatch
+		for _, loc := range re.FindAllStringIndex(b.String(), -1) {
+			if loc[1] > loc[0] {
+				matches = append(matches, PIIMatch{Type: typ, First: word(loc[0]), Last: word(loc[1] - 1)})
+			}
+		}
+		return matches
+	})
+}
+
+// RedactOptions specifies how a transcript is redacted.
+type RedactOptions struct {
+	// Detectors find the personal information to redact. They default to
+	// DefaultPIIDetectors. When matches overlap the first one is kept.
+	Detectors []PIIDetector
+
+	// Placeholder returns the text replacing a match. It defaults to the
+	// type in brackets, such as [PHONE].
+	Placeholder func(PIIType) string
+}
+
+// Redaction is personal information removed from a transcript. Element
+// indices are of the original transcript.
+type Redaction struct {
+	Type         PIIType `json:"type"`
+	Speaker      int     `json:"speaker"`
+	Monologue    int     `json:"monologue"`
+	FirstElement int     `json:"first_element"`
+	LastElement  int     `json:"last_element"`
+	Ts           float64 `json:"ts"`
+	EndTs        float64 `json:"end_ts"`
+}
+
+// Redact returns a copy of the transcript with the elements of each match
+// replaced by a single placeholder element spanning their time, and the
+// redactions made in order.
+func (t *Transcript) Redact(opts *RedactOptions) (*Transcript, []Redaction) {
+	detectors := DefaultPIIDetectors
+	placeholder := func(typ PIIType) string { return "[" + string(typ) + "]" }
+	if opts != nil && opts.Detectors != nil {
+		detectors = opts.Detectors
+	}
+	if opts != nil && opts.Placeholder != nil {
+		placeholder = opts.Placeholder
+	}
+
+	redacted := &Transcript{Monologues: make([]Monologue, len(t.Monologues))}
+
+	var redactions []Redaction
+	for i, monologue := range t.Monologues {
+		var (
+			words    []PIIWord
+			elements []int
+		)
+		for j, element := range monologue.Elements {
+			if element.Type == ElementText {
+				words = append(words, PIIWord{Text: element.Value, Ts: element.Ts, EndTs: element.EndTs})
+				elements = append(elements, j)
+			}
+		}
+
+		var matches []PIIMatch
+		for _, d := range detectors {
+			for _, m := range d.Detect(words) {
+				if m.First >= 0 && m.First <= m.Last && m.Last < len(words) {
+					matches = append(matches, m)
+				}
+			}
+		}
+		sort.SliceStable(matches, func(a, b int) bool { return matches[a].First < matches[b].First })
+
+		redacted.Monologues[i] = Monologue{Speaker: monologue.Speaker}
+		next := 0
+		for _, m := range matches {
+			first, last := elements[m.First], elements[m.Last]
+			if first < next {
+				continue
+			}
+
+			r := Redaction{
+				Type:         m.Type,
+				Speaker:      monologue.Speaker,
+				Monologue:    i,
+				FirstElement: first,
+				LastElement:  last,
+				Ts:           words[m.First].Ts,
+				EndTs:        words[m.Last].EndTs,
+			}
+			redactions = append(redactions, r)
+
+			out := &redacted.Monologues[i]
+			out.Elements = append(out.Elements, monologue.Elements[next:first]...)
+			out.Elements = append(out.Elements, Element{
+				Type:       ElementText,
+				Value:      placeholder(m.Type),
+				Ts:         r.Ts,
+				EndTs:      r.EndTs,
+				Confidence: 1,
+			})
+			next = last + 1
+		}
+		redacted.Monologues[i].Elements = append(redacted.Monologues[i].Elements, monologue.Elements[next:]...)
+	}
+
+	return redacted, redactions
+}
+
+// BleepRanges returns the time ranges of the redactions to bleep in the
+// audio, padded and with overlapping ranges merged.
+func BleepRanges(redactions []Redaction, padding time.Duration) []TimeRange {
+	var ranges []TimeRange
+	for _, r := range redactions {
+		start := secondsToDuration(r.Ts) - padding
+		if start < 0 {
+			start = 0
+		}
+		ranges = append(ranges, TimeRange{Start: start, End: secondsToDuration(r.EndTs) + padding})
+	}
+
+	return MergeTimeRanges(ranges, 0)
+}
+
+var (
+	digitWords = map[string]string{
+		"zero": "0", "oh": "0", "o": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+		"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	}
+	writtenDigits = regexp.MustCompile(`^[+(]?[0-9][0-9().\-]*$`)
+)
+
+// digitRun is a run of consecutive words said or written as digits.
+type digitRun struct {
+	first, last int
+	digits      string
+}
+
+// digitRuns returns the runs of digits in words, such as "four one one one"
+// or "555-0100". Runs do not start or end with "oh", and "double" and
+// "triple" repeat the digit after them.
+func digitRuns(words []PIIWord) []digitRun {
+	var runs []digitRun
+
+	var run *digitRun
+	weak := 0
+	flush := func() {
+		if run != nil {
+			run.last -= weak
+			run.digits = run.digits[:len(run.digits)-weak]
+			if run.digits != "" {
+				runs = append(runs, *run)
+			}
+		}
+		run, weak = nil, 0
+	}
+
+	for i := 0; i < len(words); i++ {
+		text := strings.ToLower(strings.TrimRight(words[i].Text, ".,;:!?"))
+
+		digits, repeat := "", 1
+		switch {
+		case (text == "double" || text == "triple") && i+1 < len(words):
+			next := strings.ToLower(strings.TrimRight(words[i+1].Text, ".,;:!?"))
+			if d, ok := digitWords[next]; ok {
+				if text == "triple" {
+					repeat = 3
+				} else {
+					repeat = 2
+				}
+				digits = strings.Repeat(d, repeat)
+			}
+		case writtenDigits.MatchString(text):
+			digits = strings.Map(func(r rune) rune {
+				if r >= '0' && r <= '9' {
+					return r
+				}
+				return -1
+			}, text)
+		default:
+			digits = digitWords[text]
+		}
+
+		if digits == "" {
+			flush()
+			continue
+		}
+
+		// "oh" only counts as a digit within a run.
+		isWeak := text == "oh" || text == "o"
+		if run == nil {
+			if isWeak {
+				continue
+			}
+			run = &digitRun{first: i}
+		}
+		if isWeak {
+			weak++
+		} else {
+			weak = 0
+		}
+
+		if repeat > 1 {
+			i++
+		}
+		run.last = i
+		run.digits += digits
+	}
+	flush()
+
+	return runs
+}
+
+func digitDetector(typ PIIType, match func(digits string) bool) PIIDetector {
+	return PIIDetectorFunc(func(words []PIIWord) []PIIMatch {
+		var matches []PIIMatch
+		for _, run := range digitRuns(words) {
+			if match(run.digits) {
+				matches = append(matches, PIIMatch{Type: typ, First: run.first, Last: run.last})
+			}
+		}
+		return matches
+	})
+}
+
+// luhn reports whether digits pass the Luhn checksum of card numbers.
+func luhn(digits string) bool {
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+var (
+	writtenEmail = regexp.MustCompile(`(?i)^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
+
+	emailSeparators = map[string]bool{"dot": true, "underscore": true, "dash": true, "hyphen": true}
+	emailDomains    = map[string]bool{
+		"com": true, "org": true, "net": true, "edu": true, "gov": true, "io": true,
+		"co": true, "uk": true, "us": true, "ca": true, "info": true, "biz": true,
+	}
+)
+
+func detectEmails(words []PIIWord) []PIIMatch {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = strings.ToLower(stripPunctuation(w.Text))
+	}
+
+	var matches []PIIMatch
+	for i, w := range words {
+		if writtenEmail.MatchString(strings.TrimRight(w.Text, ".,;:!?")) {
+			matches = append(matches, PIIMatch{Type: PIIEmail, First: i, Last: i})
+			continue
+		}
+
+		// a spoken address is the name before "at" and a domain after it of
+		// words separated by "dot" ending with a known top level domain.
+		if texts[i] != "at" || i == 0 || i+3 >= len(words) || texts[i-1] == "" {
+			continue
+		}
+
+		last := -1
+		for j := i + 2; j+1 < len(words) && texts[j] == "dot" && texts[j+1] != ""; j += 2 {
+			if emailDomains[texts[j+1]] {
+				last = j + 1
+			}
+		}
+		if last < 0 || texts[i+1] == "" {
+			continue
+		}
+
+		first := i - 1
+		for first >= 2 && emailSeparators[texts[first-1]] && texts[first-2] != "" {
+			first -= 2
+		}
+
+		matches = append(matches, PIIMatch{Type: PIIEmail, First: first, Last: last})
+	}
+
+	return matches
+}
@@ -0,0 +1,104 @@
+package revai
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func transcriptText(t *Transcript) string {
+	var lines []string
+	for _, m := range t.Monologues {
+		lines = append(lines, elementsText(m.Elements))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestTranscript_Redact(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		typ  PIIType
+	}{
+		{"spoken card", "my card is four one one one one one one one one one one one one one one one thanks", "my card is [CREDIT_CARD] thanks", PIICreditCard},
+		{"written card", "it's 4111-1111-1111-1111.", "it's [CREDIT_CARD].", PIICreditCard},
+		{"grouped card", "4111 1111 1111 1111 expires soon", "[CREDIT_CARD] expires soon", PIICreditCard},
+		{"spoken phone", "call five five five oh one double oh nine nine nine", "call [PHONE]", PIIPhone},
+		{"written phone", "call (555) 010-0999 today", "call [PHONE] today", PIIPhone},
+		{"ssn", "ssn is 123-45-6789", "ssn is [SSN]", PIISSN},
+		{"spoken ssn", "one two three four five six seven eight nine", "[SSN]", PIISSN},
+		{"written email", "mail jane.doe@example.com now", "mail [EMAIL] now", PIIEmail},
+		{"spoken email", "it's jane dot doe at example dot co dot uk okay", "it's [EMAIL] okay", PIIEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcript := makeTestTranscript(tt.text)
+			redacted, redactions := transcript.Redact(nil)
+
+			assert.Equal(t, tt.want, transcriptText(redacted))
+			if assert.Len(t, redactions, 1) {
+				assert.Equal(t, tt.typ, redactions[0].Type)
+			}
+		})
+	}
+}
+
+func TestTranscript_Redact_NoMatches(t *testing.T) {
+	for _, text := range []string{
+		"I have 2 kids and 3 dogs",
+		"oh one two three",
+		"the card 4111 1111 1111 1112 fails the check",
+		"look at this dot",
+	} {
+		_, redactions := makeTestTranscript(text).Redact(nil)
+		assert.Empty(t, redactions, text)
+	}
+
+	// "oh" at the end of a run is not a digit.
+	redacted, _ := makeTestTranscript("five five five one two three four oh").Redact(nil)
+	assert.Equal(t, "[PHONE] oh", transcriptText(redacted))
+}
+
+func TestTranscript_Redact_Redactions(t *testing.T) {
+	transcript := makeTestTranscript("Call 555-0100, or mail jane@example.com.")
+	transcript.Monologues[0].Speaker = 2
+
+	redacted, redactions := transcript.Redact(nil)
+
+	assert.Equal(t, "Call [PHONE], or mail [EMAIL].", transcriptText(redacted))
+	assert.Equal(t, []Redaction{
+		{Type: PIIPhone, Speaker: 2, Monologue: 0, FirstElement: 2, LastElement: 2, Ts: 0.3, EndTs: 0.6},
+		{Type: PIIEmail, Speaker: 2, Monologue: 0, FirstElement: 9, LastElement: 9, Ts: 1.2, EndTs: 1.5},
+	}, redactions)
+
+	// the original transcript is unchanged.
+	assert.Equal(t, "Call 555-0100, or mail jane@example.com.", transcriptText(transcript))
+
+	assert.Equal(t, []TimeRange{{Start: 200 * time.Millisecond, End: 700 * time.Millisecond}, {Start: 1100 * time.Millisecond, End: 1600 * time.Millisecond}}, BleepRanges(redactions, 100*time.Millisecond))
+	assert.Equal(t, []TimeRange{{Start: 0, End: 2500 * time.Millisecond}}, BleepRanges(redactions, time.Second))
+}
+
+func TestTranscript_Redact_Options(t *testing.T) {
+	transcript := makeTestTranscript("order AB-1234 for 555-0100")
+
+	redacted, redactions := transcript.Redact(&RedactOptions{
+		Detectors:   []PIIDetector{RegexpDetector("ORDER", regexp.MustCompile(`(?i)order [a-z]{2}-\d+`))},
+		Placeholder: func(typ PIIType) string { return "***" },
+	})
+
+	assert.Equal(t, "*** for 555-0100", transcriptText(redacted))
+	if assert.Len(t, redactions, 1) {
+		assert.Equal(t, Redaction{Type: "ORDER", FirstElement: 0, LastElement: 2, Ts: 0, EndTs: 0.6}, redactions[0])
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	assert.True(t, luhn("4111111111111111"))
+	assert.True(t, luhn("79927398713"))
+	assert.False(t, luhn("4111111111111112"))
+}
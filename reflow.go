@@ -0,0 +1,475 @@
+package revai
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CaptionStyle is a caption style profile used by ReflowCues and
+// ReflowTranscript to segment and time cues.
+type CaptionStyle struct {
+	MaxCharsPerLine int
+	MaxLines        int
+
+	// MaxCPS is the highest reading speed in characters per second. Cues are
+	// displayed for longer, where there is room, to stay under it.
+	MaxCPS float64
+
+	MinDuration time.Duration
+	MaxDuration time.Duration
+
+	// FrameRate is the frame rate cue timings are snapped to.
+	FrameRate FrameRate
+
+	// MinGapFrames is the shortest gap between two cues in frames.
+	MinGapFrames int
+
+	// CloseGapFrames is the gap in frames under which the end of a cue is
+	// extended to the start of the next, leaving MinGapFrames between them.
+	CloseGapFrames int
+}
+
+var (
+	// NetflixCaptionStyle follows the Netflix English timed text style guide.
+	NetflixCaptionStyle = CaptionStyle{
+		MaxCharsPerLine: 42,
+		MaxLines:        2,
+		MaxCPS:          20,
+		MinDuration:     833 * time.Millisecond,
+		MaxDuration:     7 * time.Second,
+		FrameRate:       FrameRate23976,
+		MinGapFrames:    2,
+		CloseGapFrames:  12,
+	}
+
+	// BBCCaptionStyle follows the BBC subtitle guidelines.
+	BBCCaptionStyle = CaptionStyle{
+		MaxCharsPerLine: 37,
+		MaxLines:        2,
+		MaxCPS:          15,
+		MinDuration:     time.Second,
+		MaxDuration:     8 * time.Second,
+		FrameRate:       FrameRate25,
+		MinGapFrames:    1,
+		CloseGapFrames:  10,
+	}
+)
+
+const (
+	// pauses longer than this are treated as a natural break between cues.
+	reflowLongPause = 1500 * time.Millisecond
+
+	reflowCueCost       = 2.0
+	reflowLineCost      = 1.0
+	reflowLongPauseCost = 20.0
+	reflowCPSCost       = 0.2
+	reflowMidLineCost   = 3.0
+)
+
+// reflowNoBreakAfter lists words that should stay on the same line as the word
+// that follows them, such as articles and their nouns.
+var reflowNoBreakAfter = map[string]float64{
+	"a": 10, "an": 10, "the": 10,
+	"my": 8, "your": 8, "his": 8, "her": 6, "its": 8, "our": 8, "their": 8,
+	"this": 5, "that": 4, "these": 5, "those": 5,
+	"mr.": 10, "mrs.": 10, "ms.": 10, "dr.": 10,
+	"of": 5, "to": 5, "in": 4, "on": 4, "at": 4, "for": 4, "with": 4, "from": 4, "by": 4,
+	"and": 3, "or": 3, "but": 3, "if": 3,
+	"i": 6, "very": 5, "not": 4,
+}
+
+type captionWord struct {
+	text    string
+	start   time.Duration
+	end     time.Duration
+	speaker int
+
+	// timed is true for words with their own timing from a text element.
+	timed bool
+}
+
+func (w captionWord) len() int {
+	return utf8.RuneCountInString(w.text)
+}
+
+func (s *CaptionStyle) withDefaults() CaptionStyle {
+	style := NetflixCaptionStyle
+	if s == nil {
+		return style
+	}
+
+	custom := *s
+	if custom.MaxCharsPerLine <= 0 {
+		custom.MaxCharsPerLine = style.MaxCharsPerLine
+	}
+	if custom.MaxLines <= 0 {
+		custom.MaxLines = style.MaxLines
+	}
+	if custom.MaxDuration <= 0 {
+		custom.MaxDuration = style.MaxDuration
+	}
+	if custom.FrameRate.IsZero() {
+		custom.FrameRate = style.FrameRate
+	}
+	if custom.MinGapFrames < 0 {
+		custom.MinGapFrames = 0
+	}
+
+	return custom
+}
+
+// ReflowTranscript segments the transcript into cues that follow style, using
+// the timing of each element. A nil style uses NetflixCaptionStyle.
+func ReflowTranscript(t *Transcript, style *CaptionStyle) []Cue {
+	return segmentCues(reflow(transcriptWords(t), style.withDefaults()))
+}
+
+// ReflowCues re-segments and re-times cues to follow style. Word timings are
+// estimated by sharing each cue's duration out by character count. Markup is
+// removed, and cues with different voices are never merged.
+// A nil style uses NetflixCaptionStyle.
+func ReflowCues(cues []Cue, style *CaptionStyle) []Cue {
+	var (
+		words  []captionWord
+		voices = map[string]int{}
+	)
+
+	for _, cue := range cues {
+		speaker, ok := voices[cue.Voice()]
+		if !ok {
+			speaker = len(voices)
+			voices[cue.Voice()] = speaker
+		}
+
+		fields := strings.Fields(cue.PlainText())
+
+		total := 0
+		for _, field := range fields {
+			total += utf8.RuneCountInString(field)
+		}
+
+		start := cue.Start
+		seen := 0
+		for _, field := range fields {
+			seen += utf8.RuneCountInString(field)
+			end := cue.Start + cue.Duration()*time.Duration(seen)/time.Duration(total)
+			words = append(words, captionWord{text: field, start: start, end: end, speaker: speaker})
+			start = end
+		}
+	}
+
+	return segmentCues(reflow(words, style.withDefaults()))
+}
+
+// transcriptWords returns the words of a transcript with punctuation
+// attached to the word before it.
+func transcriptWords(t *Transcript) []captionWord {
+	var words []captionWord
+
+	for _, monologue := range t.Monologues {
+		first := len(words)
+		for _, element := range monologue.Elements {
+			if element.Type != ElementPunct {
+				words = append(words, captionWord{
+					text:    element.Value,
+					start:   secondsToDuration(element.Ts),
+					end:     secondsToDuration(element.EndTs),
+					speaker: monologue.Speaker,
+					timed:   element.Type == ElementText,
+				})
+				continue
+			}
+
+			value := strings.TrimSpace(element.Value)
+			if value == "" || len(words) == first {
+				continue
+			}
+			words[len(words)-1].text += value
+		}
+	}
+
+	return words
+}
+
+// captionSegment is a cue created from transcript words along with the
+// speaker and words it was made from.
+type captionSegment struct {
+	cue     Cue
+	speaker int
+	words   []captionWord
+}
+
+// lines splits the segment words into the lines of its cue.
+func (seg captionSegment) lines() [][]captionWord {
+	var (
+		lines [][]captionWord
+		next  int
+	)
+
+	for _, line := range seg.cue.Lines {
+		start := next
+		for next < len(seg.words) {
+			next++
+			if joinWords(seg.words[start:next]) == line {
+				break
+			}
+		}
+		lines = append(lines, seg.words[start:next])
+	}
+
+	return lines
+}
+
+func segmentCues(segments []captionSegment) []Cue {
+	cues := make([]Cue, len(segments))
+	for i, seg := range segments {
+		cues[i] = seg.cue
+	}
+	return cues
+}
+
+func reflow(words []captionWord, style CaptionStyle) []captionSegment {
+	if len(words) == 0 {
+		return nil
+	}
+
+	n := len(words)
+	best := make([]float64, n+1)
+	from := make([]int, n+1)
+	lines := make([][]string, n+1)
+
+	for j := 1; j <= n; j++ {
+		best[j] = math.Inf(1)
+
+		chars := 0
+		for i := j - 1; i >= 0; i-- {
+			chars += words[i].len()
+			if i < j-1 {
+				chars++
+			}
+
+			single := i == j-1
+			if !single {
+				if chars > style.MaxCharsPerLine*style.MaxLines {
+					break
+				}
+				if words[i].speaker != words[j-1].speaker {
+					break
+				}
+				if words[j-1].end-words[i].start > style.MaxDuration {
+					break
+				}
+			}
+
+			layout, cost := layoutLines(words[i:j], style)
+			if layout == nil {
+				if single {
+					layout = []string{words[i].text}
+				} else {
+					continue
+				}
+			}
+
+			cost += best[i] + reflowCueCost + segmentCost(words, i, j, style)
+			if cost < best[j] {
+				best[j] = cost
+				from[j] = i
+				lines[j] = layout
+			}
+		}
+	}
+
+	var segments []captionSegment
+	for j := n; j > 0; j = from[j] {
+		i := from[j]
+		segments = append(segments, captionSegment{
+			cue:     Cue{Start: words[i].start, End: words[j-1].end, Lines: lines[j]},
+			speaker: words[i].speaker,
+			words:   words[i:j],
+		})
+	}
+
+	for l, r := 0, len(segments)-1; l < r; l, r = l+1, r-1 {
+		segments[l], segments[r] = segments[r], segments[l]
+	}
+
+	cues := retime(segmentCues(segments), style)
+	for i := range segments {
+		segments[i].cue = cues[i]
+	}
+
+	return segments
+}
+
+// segmentCost scores a cue made of words[i:j] by where it breaks, the pauses
+// it spans and how fast it has to be read.
+func segmentCost(words []captionWord, i, j int, style CaptionStyle) float64 {
+	var cost float64
+
+	if j < len(words) {
+		cost += breakCost(words[j-1], words[j])
+	}
+
+	chars := 0
+	for k := i; k < j; k++ {
+		chars += words[k].len()
+		if k > i && words[k].start-words[k-1].end >= reflowLongPause {
+			cost += reflowLongPauseCost
+		}
+	}
+
+	if style.MaxCPS > 0 {
+		available := words[j-1].end - words[i].start
+		if j < len(words) {
+			available = words[j].start - words[i].start
+		} else if available < style.MaxDuration {
+			available = style.MaxDuration
+		}
+
+		if excess := float64(chars) - style.MaxCPS*available.Seconds(); excess > 0 {
+			cost += reflowCPSCost * excess
+		}
+	}
+
+	return cost
+}
+
+// breakCost scores breaking a cue or line between a and b. Breaking after the
+// end of a sentence is free while breaking an article from its noun is costly.
+func breakCost(a, b captionWord) float64 {
+	last, _ := utf8.DecodeLastRuneInString(a.text)
+
+	switch {
+	case strings.ContainsRune(".?!", last):
+		return 0
+	case strings.ContainsRune(",;:", last) || b.start-a.end >= 500*time.Millisecond:
+		return 1
+	}
+
+	if cost, ok := reflowNoBreakAfter[strings.ToLower(a.text)]; ok {
+		return cost
+	}
+
+	if first, _ := utf8.DecodeRuneInString(b.text); unicode.IsPunct(first) {
+		return 10
+	}
+
+	return 3
+}
+
+// layoutLines breaks words into at most style.MaxLines lines that fit
+// style.MaxCharsPerLine, preferring good break points and balanced lines.
+// It returns nil when the words do not fit.
+func layoutLines(words []captionWord, style CaptionStyle) ([]string, float64) {
+	var (
+		bestLines []string
+		bestCost  = math.Inf(1)
+	)
+
+	var search func(start int, lines []string, cost float64)
+	search = func(start int, lines []string, cost float64) {
+		if len(lines) == style.MaxLines {
+			return
+		}
+
+		chars := 0
+		for end := start + 1; end <= len(words); end++ {
+			chars += words[end-1].len()
+			if end > start+1 {
+				chars++
+			}
+			if chars > style.MaxCharsPerLine {
+				return
+			}
+
+			line := joinWords(words[start:end])
+			if end > start+1 && breakCost(words[end-2], words[end-1]) == 0 {
+				// sentences should end at the end of a line.
+				cost += reflowMidLineCost
+			}
+
+			if end == len(words) {
+				total := cost + float64(len(lines))*reflowLineCost + balanceCost(append(lines, line), style)
+				if total < bestCost {
+					bestCost = total
+					bestLines = append(append([]string(nil), lines...), line)
+				}
+				return
+			}
+
+			search(end, append(lines, line), cost+breakCost(words[end-1], words[end]))
+		}
+	}
+
+	search(0, nil, 0)
+
+	return bestLines, bestCost
+}
+
+// balanceCost prefers lines of similar length, with the bottom line the longest.
+func balanceCost(lines []string, style CaptionStyle) float64 {
+	var cost float64
+	for k := 1; k < len(lines); k++ {
+		top := utf8.RuneCountInString(lines[k-1])
+		bottom := utf8.RuneCountInString(lines[k])
+		diff := float64(top-bottom) / float64(style.MaxCharsPerLine)
+		if diff > 0 {
+			diff *= 2
+		}
+		cost += math.Abs(diff) * 2
+	}
+	return cost
+}
+
+func joinWords(words []captionWord) string {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.text
+	}
+	return strings.Join(texts, " ")
+}
+
+// retime snaps cues to frames and applies the minimum and maximum durations,
+// reading speed and minimum gap of style.
+func retime(cues []Cue, style CaptionStyle) []Cue {
+	rate := style.FrameRate
+	toFrame := func(d time.Duration) int { return NewTimecode(d, rate, TimecodeRoundNearest).Frames }
+	toDuration := func(f int) time.Duration { return Timecode{Frames: f, Rate: rate}.Duration() }
+
+	starts := make([]int, len(cues))
+	for i, cue := range cues {
+		starts[i] = toFrame(cue.Start)
+	}
+
+	for i := range cues {
+		start := starts[i]
+		end := toFrame(cues[i].End)
+
+		wanted := style.readingTime(plainChars(cues[i].Lines))
+		if min := start + NewTimecode(wanted, rate, TimecodeRoundUp).Frames; end < min {
+			end = min
+		}
+
+		if i+1 < len(cues) {
+			limit := starts[i+1] - style.MinGapFrames
+			if end > limit || limit-end < style.CloseGapFrames {
+				end = limit
+			}
+		}
+
+		if end <= start {
+			end = start + 1
+			if i+1 < len(cues) && starts[i+1] < end {
+				starts[i+1] = end
+			}
+		}
+
+		cues[i].Start = toDuration(start)
+		cues[i].End = toDuration(end)
+	}
+
+	return cues
+}
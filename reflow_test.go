@@ -0,0 +1,99 @@
+package revai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTestTranscript creates a single speaker transcript where each word
+// takes 300ms.
+func makeTestTranscript(text string) *Transcript {
+	var elements []Element
+	ts := 0.0
+	for i, word := range strings.Fields(text) {
+		if i > 0 {
+			elements = append(elements, Element{Type: "punct", Value: " "})
+		}
+
+		punct := strings.TrimRight(word, ".,?!")
+		elements = append(elements, Element{Type: "text", Value: punct, Ts: ts, EndTs: ts + 0.3, Confidence: 1})
+		if punct != word {
+			elements = append(elements, Element{Type: "punct", Value: word[len(punct):]})
+		}
+		ts += 0.3
+	}
+
+	return &Transcript{Monologues: []Monologue{{Elements: elements}}}
+}
+
+func TestReflowTranscript(t *testing.T) {
+	cues := ReflowTranscript(testTranscript, nil)
+
+	if !assert.Len(t, cues, 2) {
+		return
+	}
+
+	assert.Equal(t, []string{"Hello, my name is Jane."}, cues[0].Lines)
+	assert.Equal(t, []string{"Nice to meet you."}, cues[1].Lines)
+
+	// the first cue ends two frames before the second starts.
+	gap := cues[1].Start - cues[0].End
+	assert.InDelta(t, float64(2*time.Second)/NetflixCaptionStyle.FrameRate.FPS(), float64(gap), float64(time.Millisecond))
+}
+
+func TestReflowTranscript_LineBreaks(t *testing.T) {
+	transcript := makeTestTranscript("We walked all the way down to the old harbour and watched the fishing boats come in.")
+
+	style := BBCCaptionStyle
+	cues := ReflowTranscript(transcript, &style)
+
+	for _, cue := range cues {
+		assert.LessOrEqual(t, len(cue.Lines), style.MaxLines)
+		for _, line := range cue.Lines {
+			assert.LessOrEqual(t, len(line), style.MaxCharsPerLine)
+
+			last := strings.ToLower(line[strings.LastIndex(line, " ")+1:])
+			assert.NotContains(t, []string{"a", "an", "the"}, last, "line %q ends with an article", line)
+		}
+	}
+}
+
+func TestReflowTranscript_Timing(t *testing.T) {
+	transcript := makeTestTranscript("Yes. No. Maybe later, if we have time.")
+
+	style := CaptionStyle{MaxCharsPerLine: 10, MaxLines: 1, MaxCPS: 10, MinDuration: time.Second, FrameRate: FrameRate25, MinGapFrames: 2}
+	cues := ReflowTranscript(transcript, &style)
+
+	for i, cue := range cues {
+		assert.Equal(t, time.Duration(0), cue.Start%(40*time.Millisecond), "start is on a frame")
+		assert.Equal(t, time.Duration(0), cue.End%(40*time.Millisecond), "end is on a frame")
+		if i+1 < len(cues) {
+			assert.GreaterOrEqual(t, int64(cues[i+1].Start-cue.End), int64(80*time.Millisecond))
+		}
+	}
+	assert.GreaterOrEqual(t, int64(cues[len(cues)-1].Duration()), int64(time.Second))
+}
+
+func TestReflowCues(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 500 * time.Millisecond, Lines: []string{"<v Jane>So"}},
+		{Start: 500 * time.Millisecond, End: 5 * time.Second, Lines: []string{"<v Jane>this is a very long cue that goes on and on about nothing in particular."}},
+		{Start: 5 * time.Second, End: 6 * time.Second, Lines: []string{"<v Bob>Right."}},
+	}
+
+	reflowed := ReflowCues(cues, nil)
+
+	var text []string
+	for _, cue := range reflowed {
+		text = append(text, strings.Join(cue.Lines, " "))
+		for _, line := range cue.Lines {
+			assert.LessOrEqual(t, len(line), NetflixCaptionStyle.MaxCharsPerLine)
+		}
+	}
+
+	assert.Equal(t, "So this is a very long cue that goes on and on about nothing in particular. Right.", strings.Join(text, " "))
+	assert.Equal(t, "Right.", text[len(text)-1])
+}
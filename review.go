@@ -0,0 +1,257 @@
+package revai
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ReviewOptions specifies how low confidence spans are found.
+type ReviewOptions struct {
+	// Threshold is the confidence below which a word is low confidence.
+	// It defaults to 0.6.
+	Threshold float64
+
+	// Window is the number of words in the sliding window and Density the
+	// fraction of low confidence words a window must hold for them to be
+	// flagged, so isolated dips are ignored. They default to 5 and 0.4.
+	Window  int
+	Density float64
+
+	// MergeGap is the longest gap between spans of a monologue which are
+	// merged. It defaults to 2 seconds.
+	MergeGap time.Duration
+
+	// Context is the number of words before and after a span in its context.
+	// It defaults to 5, a negative value leaves out the context.
+	Context int
+
+	// Padding is added before and after a span to its audio range. It
+	// defaults to half a second.
+	Padding time.Duration
+}
+
+func (o *ReviewOptions) withDefaults() ReviewOptions {
+	opts := ReviewOptions{
+		Threshold: 0.6,
+		Window:    5,
+		Density:   0.4,
+		MergeGap:  2 * time.Second,
+		Context:   5,
+		Padding:   500 * time.Millisecond,
+	}
+
+	if o == nil {
+		return opts
+	}
+
+	if o.Threshold > 0 {
+		opts.Threshold = o.Threshold
+	}
+	if o.Window > 0 {
+		opts.Window = o.Window
+	}
+	if o.Density > 0 {
+		opts.Density = o.Density
+	}
+	if o.MergeGap > 0 {
+		opts.MergeGap = o.MergeGap
+	}
+	if o.Context < 0 {
+		opts.Context = 0
+	} else if o.Context > 0 {
+		opts.Context = o.Context
+	}
+	if o.Padding > 0 {
+		opts.Padding = o.Padding
+	}
+
+	return opts
+}
+
+// ReviewSpan is a span of a monologue with low confidence words for a human
+// to review. Ts and EndTs are the timing of its words and AudioStart and
+// AudioEnd the padded range of audio to play, all in seconds.
+type ReviewSpan struct {
+	Speaker      int     `json:"speaker"`
+	Monologue    int     `json:"monologue"`
+	FirstElement int     `json:"first_element"`
+	LastElement  int     `json:"last_element"`
+	Ts           float64 `json:"ts"`
+	EndTs        float64 `json:"end_ts"`
+	AudioStart   float64 `json:"audio_start"`
+	AudioEnd     float64 `json:"audio_end"`
+
+	Words          int     `json:"words"`
+	LowConfidence  int     `json:"low_confidence"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	MinConfidence  float64 `json:"min_confidence"`
+
+	Text   string `json:"text"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// LowConfidenceSpans returns the spans of the transcript where confidence
+// falls below the threshold, in order.
+func (t *Transcript) LowConfidenceSpans(opts *ReviewOptions) []ReviewSpan {
+	o := opts.withDefaults()
+
+	type word struct {
+		monologue, element int
+		Element
+	}
+
+	var words []word
+	for i, monologue := range t.Monologues {
+		for j, element := range monologue.Elements {
+			if element.Type == ElementText {
+				words = append(words, word{i, j, element})
+			}
+		}
+	}
+
+	low := func(i int) bool { return words[i].Confidence < o.Threshold }
+
+	// flagged marks the words from the first to the last low confidence word
+	// of each dense window.
+	flagged := make([]bool, len(words))
+	window := o.Window
+	if window > len(words) {
+		window = len(words)
+	}
+	for start := 0; start+window <= len(words) && window > 0; start++ {
+		first, last, count := -1, -1, 0
+		for i := start; i < start+window; i++ {
+			if low(i) {
+				if first < 0 {
+					first = i
+				}
+				last = i
+				count++
+			}
+		}
+		if count == 0 || float64(count) < o.Density*float64(window) {
+			continue
+		}
+		for i := first; i <= last; i++ {
+			flagged[i] = true
+		}
+	}
+
+	// runs of flagged words within a monologue, merged when close together.
+	type run struct{ first, last int }
+	var runs []run
+	for i := range words {
+		if !flagged[i] {
+			continue
+		}
+		if n := len(runs); n > 0 {
+			prev := &runs[n-1]
+			sameMonologue := words[prev.last].monologue == words[i].monologue
+			gap := secondsToDuration(words[i].Ts - words[prev.last].EndTs)
+			if sameMonologue && (prev.last == i-1 || gap <= o.MergeGap) {
+				prev.last = i
+				continue
+			}
+		}
+		runs = append(runs, run{i, i})
+	}
+
+	var spans []ReviewSpan
+	for _, r := range runs {
+		first, last := words[r.first], words[r.last]
+		monologue := t.Monologues[first.monologue]
+
+		span := ReviewSpan{
+			Speaker:       monologue.Speaker,
+			Monologue:     first.monologue,
+			FirstElement:  first.element,
+			LastElement:   last.element,
+			Ts:            first.Ts,
+			EndTs:         last.EndTs,
+			AudioStart:    math.Max(0, first.Ts-o.Padding.Seconds()),
+			AudioEnd:      last.EndTs + o.Padding.Seconds(),
+			MinConfidence: 1,
+			Text:          elementsText(monologue.Elements[first.element : last.element+1]),
+		}
+
+		var total float64
+		for i := r.first; i <= r.last; i++ {
+			c := words[i].Confidence
+			span.Words++
+			total += c
+			span.MinConfidence = math.Min(span.MinConfidence, c)
+			if low(i) {
+				span.LowConfidence++
+			}
+		}
+		span.MeanConfidence = total / float64(span.Words)
+
+		before, after := first.element, last.element
+		for i, n := r.first-1, 0; i >= 0 && n < o.Context && words[i].monologue == first.monologue; i, n = i-1, n+1 {
+			before = words[i].element
+		}
+		for i, n := r.last+1, 0; i < len(words) && n < o.Context && words[i].monologue == first.monologue; i, n = i+1, n+1 {
+			after = words[i].element
+		}
+		for after+1 < len(monologue.Elements) && monologue.Elements[after+1].Type == ElementPunct {
+			after++
+		}
+		span.Before = elementsText(monologue.Elements[before:first.element])
+		span.After = elementsText(monologue.Elements[last.element+1 : after+1])
+
+		spans = append(spans, span)
+	}
+
+	return spans
+}
+
+// EncodeReviewJSON writes spans to w as a JSON review worklist.
+func EncodeReviewJSON(w io.Writer, spans []ReviewSpan) error {
+	if spans == nil {
+		spans = []ReviewSpan{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(spans)
+}
+
+// EncodeReviewCSV writes spans to w as a CSV review worklist with a row per
+// span.
+func EncodeReviewCSV(w io.Writer, spans []ReviewSpan) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"speaker", "start", "end", "audio_start", "audio_end", "words", "low_confidence", "mean_confidence", "min_confidence", "before", "text", "after"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	seconds := func(s float64) string { return strconv.FormatFloat(s, 'f', 3, 64) }
+	for _, s := range spans {
+		record := []string{
+			strconv.Itoa(s.Speaker),
+			seconds(s.Ts),
+			seconds(s.EndTs),
+			seconds(s.AudioStart),
+			seconds(s.AudioEnd),
+			strconv.Itoa(s.Words),
+			strconv.Itoa(s.LowConfidence),
+			strconv.FormatFloat(s.MeanConfidence, 'f', 2, 64),
+			strconv.FormatFloat(s.MinConfidence, 'f', 2, 64),
+			s.Before,
+			s.Text,
+			s.After,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,97 @@
+package revai
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// makeReviewTranscript returns a transcript of numbered words where the words
+// at the low indexes have a confidence of 0.3.
+func makeReviewTranscript(n int, low ...int) *Transcript {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = "w" + strings.Repeat("x", i%3)
+	}
+	words[n-1] += "."
+
+	t := makeTestTranscript(strings.Join(words, " "))
+	for _, i := range low {
+		t.Monologues[0].Elements[i*2].Confidence = 0.3
+	}
+	return t
+}
+
+func TestTranscript_LowConfidenceSpans(t *testing.T) {
+	// the dip at word 2 is isolated, words 10 to 13 are flagged.
+	transcript := makeReviewTranscript(20, 2, 10, 11, 13)
+
+	spans := transcript.LowConfidenceSpans(&ReviewOptions{Context: 2})
+
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+
+	span := spans[0]
+	assert.Equal(t, 20, span.FirstElement)
+	assert.Equal(t, 26, span.LastElement)
+	assert.InDelta(t, 3.0, span.Ts, 1e-9)
+	assert.InDelta(t, 4.2, span.EndTs, 1e-9)
+	assert.InDelta(t, 2.5, span.AudioStart, 1e-9)
+	assert.InDelta(t, 4.7, span.AudioEnd, 1e-9)
+	assert.Equal(t, 4, span.Words)
+	assert.Equal(t, 3, span.LowConfidence)
+	assert.InDelta(t, 0.475, span.MeanConfidence, 1e-9)
+	assert.InDelta(t, 0.3, span.MinConfidence, 1e-9)
+	assert.Equal(t, "wxx w", span.Before)
+	assert.Equal(t, "wx wxx w wx", span.Text)
+	assert.Equal(t, "wxx w", span.After)
+}
+
+func TestTranscript_LowConfidenceSpans_Merge(t *testing.T) {
+	transcript := makeReviewTranscript(30, 10, 11, 16, 17)
+
+	assert.Len(t, transcript.LowConfidenceSpans(nil), 1)
+	assert.Len(t, transcript.LowConfidenceSpans(&ReviewOptions{MergeGap: 500 * time.Millisecond}), 2)
+
+	// spans do not cross monologues.
+	transcript.Monologues = append(transcript.Monologues, Monologue{Speaker: 1, Elements: transcript.Monologues[0].Elements[28:]})
+	transcript.Monologues[0].Elements = transcript.Monologues[0].Elements[:28]
+	spans := transcript.LowConfidenceSpans(nil)
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, 0, spans[0].Speaker)
+		assert.Equal(t, 1, spans[1].Speaker)
+		assert.Equal(t, 4, spans[1].FirstElement)
+	}
+
+	assert.Empty(t, (&Transcript{}).LowConfidenceSpans(nil))
+	assert.Len(t, makeReviewTranscript(2, 0).LowConfidenceSpans(nil), 1)
+}
+
+func TestEncodeReviewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeReviewJSON(&buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf.Reset()
+	spans := makeReviewTranscript(20, 10, 11).LowConfidenceSpans(nil)
+	assert.NoError(t, EncodeReviewJSON(&buf, spans))
+
+	var decoded []ReviewSpan
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, spans, decoded)
+}
+
+func TestEncodeReviewCSV(t *testing.T) {
+	var buf bytes.Buffer
+	spans := makeReviewTranscript(20, 10, 11).LowConfidenceSpans(&ReviewOptions{Context: 1})
+	assert.NoError(t, EncodeReviewCSV(&buf, spans))
+
+	expected := "speaker,start,end,audio_start,audio_end,words,low_confidence,mean_confidence,min_confidence,before,text,after\n" +
+		"0,3.000,3.600,2.500,4.100,2,2,0.30,0.30,w,wx wxx,w\n"
+	assert.Equal(t, expected, buf.String())
+}
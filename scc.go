@@ -0,0 +1,759 @@
+package revai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	sccHeader     = "Scenarist_SCC V1.0"
+	sccColumns    = 32
+	sccMaxRows    = 4
+	sccDefaultRow = 15
+)
+
+// sccFrameRate is the rate of SCC timecodes.
+var sccFrameRate = FrameRate2997DF
+
+// SCCMode is the CEA-608 mode captions are displayed in.
+type SCCMode int
+
+const (
+	// SCCPopOn loads each cue off screen and displays it all at once.
+	SCCPopOn SCCMode = iota
+	// SCCRollUp scrolls each line up from the bottom of the caption window.
+	SCCRollUp
+	// SCCPaintOn paints each cue directly on screen as it is received.
+	SCCPaintOn
+)
+
+// CEA-608 channel 1 control codes without parity.
+const (
+	sccRCL = 0x1420 // resume caption loading
+	sccBS  = 0x1421 // backspace
+	sccDER = 0x1424 // delete to end of row
+	sccRU2 = 0x1425 // roll-up captions 2 rows
+	sccRU3 = 0x1426 // roll-up captions 3 rows
+	sccRU4 = 0x1427 // roll-up captions 4 rows
+	sccRDC = 0x1429 // resume direct captioning
+	sccEDM = 0x142c // erase displayed memory
+	sccCR  = 0x142d // carriage return
+	sccENM = 0x142e // erase non-displayed memory
+	sccEOC = 0x142f // end of caption
+)
+
+// pacRows maps a row number to the first byte of its preamble address code
+// and the base of the second byte.
+var pacRows = [16]struct {
+	b1   byte
+	base byte
+}{
+	1: {0x11, 0x40}, 2: {0x11, 0x60}, 3: {0x12, 0x40}, 4: {0x12, 0x60},
+	5: {0x15, 0x40}, 6: {0x15, 0x60}, 7: {0x16, 0x40}, 8: {0x16, 0x60},
+	9: {0x17, 0x40}, 10: {0x17, 0x60}, 11: {0x10, 0x40}, 12: {0x13, 0x40},
+	13: {0x13, 0x60}, 14: {0x14, 0x40}, 15: {0x14, 0x60},
+}
+
+// sccBasicChars lists the characters of the basic CEA-608 set that differ
+// from ASCII.
+var sccBasicChars = map[byte]rune{
+	0x2a: 'á', 0x5c: 'é', 0x5e: 'í', 0x5f: 'ó', 0x60: 'ú',
+	0x7b: 'ç', 0x7c: '÷', 0x7d: 'Ñ', 0x7e: 'ñ', 0x7f: '█',
+}
+
+// sccSpecialChars lists the special characters sent as 0x11 0x30-0x3f.
+var sccSpecialChars = []rune("®°½¿™¢£♪à èâêîôû")
+
+// sccExtendedChars lists the extended characters sent as 0x12 0x20-0x3f
+// followed by 0x13 0x20-0x3f.
+var sccExtendedChars = []rune(
+	"ÁÉÓÚÜü‘¡*’—©℠•“”ÀÂÇÈÊËëÎÏïÔÙùÛ«»" +
+		"ÃãÍÌìÒòÕõ{}\\^_|~ÄäÖöß¥¤¦ÅåØø┌┐└┘")
+
+// sccExtendedFallback is the basic character sent before an extended character
+// for decoders that do not support the extended set.
+var sccExtendedFallback = []byte(
+	"AEOUUu'!.'-c .\"\"AACEEEeIIiOUuU\"\"" +
+		"AaIIiOoOo()/ -/-AaOosY /AaOo++++")
+
+var (
+	sccEncodeBasic    = map[rune]byte{}
+	sccEncodeSpecial  = map[rune]byte{}
+	sccEncodeExtended = map[rune]uint16{}
+)
+
+func init() {
+	for b := byte(0x20); b < 0x80; b++ {
+		r, ok := sccBasicChars[b]
+		if !ok {
+			r = rune(b)
+		}
+		sccEncodeBasic[r] = b
+	}
+	for i, r := range sccSpecialChars {
+		sccEncodeSpecial[r] = byte(0x30 + i)
+	}
+	for i, r := range sccExtendedChars {
+		sccEncodeExtended[r] = uint16(0x1220+i/32*0x100) | uint16(i%32)
+	}
+}
+
+// SCCOptions specifies how cues are encoded by EncodeSCC.
+type SCCOptions struct {
+	Mode SCCMode
+
+	// RollUpRows is the number of rows visible in roll-up mode. It must be 2, 3 or 4.
+	RollUpRows int
+
+	// BaseRow is the bottom row captions are placed on, between 4 and 15.
+	BaseRow int
+
+	// StartTimecode is added to the cue timings, for example 01:00:00;00.
+	StartTimecode Timecode
+}
+
+// EncodeSCC writes cues to w as a Scenarist SCC file of CEA-608 byte pairs
+// using SMPTE drop-frame timecodes at 29.97fps.
+// Lines longer than 32 columns are wrapped and cues with more than 4 rows are
+// split into consecutive cues. Cues that are too close together to be sent in
+// time are delayed until the previous caption data has been sent.
+func EncodeSCC(w io.Writer, cues []Cue, opts *SCCOptions) error {
+	o := SCCOptions{Mode: SCCPopOn, RollUpRows: 2, BaseRow: sccDefaultRow}
+	if opts != nil {
+		o.Mode = opts.Mode
+		if opts.RollUpRows != 0 {
+			o.RollUpRows = opts.RollUpRows
+		}
+		if opts.BaseRow != 0 {
+			o.BaseRow = opts.BaseRow
+		}
+		o.StartTimecode = opts.StartTimecode
+	}
+
+	if o.RollUpRows < 2 || o.RollUpRows > 4 {
+		return fmt.Errorf("invalid roll-up rows %d", o.RollUpRows)
+	}
+
+	if o.BaseRow < sccMaxRows || o.BaseRow > sccDefaultRow {
+		return fmt.Errorf("invalid base row %d", o.BaseRow)
+	}
+
+	enc := &sccEncoder{opts: o}
+
+	blocks, err := enc.blocks(sccSplitCues(cues))
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(sccHeader + "\n"); err != nil {
+		return err
+	}
+
+	start := o.StartTimecode.Convert(sccFrameRate, TimecodeRoundNearest)
+
+	next := 0
+	for _, b := range blocks {
+		frame := b.frame
+		if frame < next {
+			frame = next
+		}
+		next = frame + len(b.words)
+
+		hex := make([]string, len(b.words))
+		for i, word := range b.words {
+			hex[i] = fmt.Sprintf("%04x", word)
+		}
+
+		if _, err := fmt.Fprintf(bw, "\n%s\t%s\n", start.Add(frame), strings.Join(hex, " ")); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+type sccBlock struct {
+	frame int
+	words []uint16
+}
+
+type sccEncoder struct {
+	opts SCCOptions
+}
+
+func (e *sccEncoder) blocks(cues []Cue) ([]sccBlock, error) {
+	var blocks []sccBlock
+
+	for i, cue := range cues {
+		start := sccFrames(cue.Start)
+		end := sccFrames(cue.End)
+		if end <= start {
+			return nil, fmt.Errorf("cue %d ends before it starts", i)
+		}
+
+		// the previous cue is only cleared when this cue does not replace it
+		// straight away, which may need to happen while this cue is loading.
+		clearFrame := -1
+		if i > 0 && sccFrames(cues[i-1].End) < start {
+			clearFrame = sccFrames(cues[i-1].End)
+		}
+
+		var (
+			words []uint16
+			shown int
+		)
+
+		switch e.opts.Mode {
+		case SCCPopOn:
+			words = sccDouble(sccRCL, sccENM)
+			rows, _ := e.rows(cue.Lines)
+			words = append(words, rows...)
+			shown = len(words)
+			words = append(words, sccDouble(sccEOC)...)
+		case SCCPaintOn:
+			words = sccDouble(sccRDC)
+			if i > 0 && clearFrame < 0 {
+				words = append(words, sccDouble(sccEDM)...)
+			}
+			rows, first := e.rows(cue.Lines)
+			shown = len(words) + first
+			words = append(words, rows...)
+		case SCCRollUp:
+			ru := [...]uint16{2: sccRU2, 3: sccRU3, 4: sccRU4}[e.opts.RollUpRows]
+			for j, line := range cue.Lines {
+				words = append(words, sccDouble(ru, sccCR)...)
+				words = append(words, e.pac(e.opts.BaseRow, line)...)
+				if j == 0 {
+					shown = len(words)
+				}
+				words = append(words, e.text(line)...)
+			}
+		default:
+			return nil, fmt.Errorf("invalid scc mode %d", e.opts.Mode)
+		}
+
+		frame := start - shown
+		if clearFrame >= 0 {
+			if clearFrame+2 <= frame {
+				blocks = append(blocks, sccBlock{frame: clearFrame, words: sccDouble(sccEDM)})
+			} else {
+				// not enough room before the cue data, so erase the display
+				// in the middle of loading instead.
+				frame -= 2
+				at := sccSplitPoint(words[:shown], clearFrame-frame)
+				words = append(words[:at], append(sccDouble(sccEDM), words[at:]...)...)
+			}
+		}
+
+		blocks = append(blocks, sccBlock{frame: frame, words: words})
+
+		if i == len(cues)-1 {
+			blocks = append(blocks, sccBlock{frame: end, words: sccDouble(sccEDM)})
+		}
+	}
+
+	return blocks, nil
+}
+
+// rows encodes lines at the bottom of the caption area. It also returns the
+// index of the first character word.
+func (e *sccEncoder) rows(lines []string) ([]uint16, int) {
+	var (
+		words []uint16
+		first int
+	)
+
+	row := e.opts.BaseRow - len(lines) + 1
+	for i, line := range lines {
+		words = append(words, e.pac(row+i, line)...)
+		if i == 0 {
+			first = len(words)
+		}
+		words = append(words, e.text(line)...)
+	}
+
+	return words, first
+}
+
+// pac positions the cursor so that line is centered on row.
+func (e *sccEncoder) pac(row int, line string) []uint16 {
+	col := (sccColumns - utf8.RuneCountInString(line)) / 2
+	if e.opts.Mode == SCCRollUp || col < 0 {
+		col = 0
+	}
+
+	p := pacRows[row]
+	words := sccDouble(uint16(p.b1)<<8 | uint16(p.base+0x10+byte(col/4)<<1))
+	if col%4 != 0 {
+		words = append(words, sccDouble(0x1720|uint16(col%4))...)
+	}
+
+	return words
+}
+
+// text encodes line as CEA-608 character byte pairs.
+func (e *sccEncoder) text(line string) []uint16 {
+	var (
+		words   []uint16
+		pending []byte
+	)
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if len(pending) == 1 {
+			pending = append(pending, 0)
+		}
+		words = append(words, sccWord(uint16(pending[0])<<8|uint16(pending[1])))
+		pending = pending[:0]
+	}
+
+	for _, r := range line {
+		if b, ok := sccEncodeBasic[r]; ok {
+			pending = append(pending, b)
+			if len(pending) == 2 {
+				flush()
+			}
+			continue
+		}
+
+		if b, ok := sccEncodeSpecial[r]; ok {
+			flush()
+			words = append(words, sccDouble(0x1100|uint16(b))...)
+			continue
+		}
+
+		if code, ok := sccEncodeExtended[r]; ok {
+			i := int(code>>8-0x12)*32 + int(code&0xff-0x20)
+			pending = append(pending, sccExtendedFallback[i])
+			flush()
+			words = append(words, sccDouble(code)...)
+			continue
+		}
+
+		// characters outside of the CEA-608 character set are dropped.
+	}
+
+	flush()
+
+	return words
+}
+
+// sccSplitCues removes markup from cues, wraps cue lines to 32 columns and splits cues with too many rows
+// into consecutive cues, sharing the display time out by character count.
+func sccSplitCues(cues []Cue) []Cue {
+	var out []Cue
+
+	for _, cue := range cues {
+		var lines []string
+		for _, line := range cue.PlainLines() {
+			if utf8.RuneCountInString(line) <= sccColumns {
+				lines = append(lines, line)
+				continue
+			}
+			lines = append(lines, wrapText(line, sccColumns)...)
+		}
+
+		if len(lines) <= sccMaxRows {
+			out = append(out, Cue{Start: cue.Start, End: cue.End, Lines: lines})
+			continue
+		}
+
+		total := utf8.RuneCountInString(strings.Join(lines, ""))
+		start := cue.Start
+		seen := 0
+		for i := 0; i < len(lines); i += sccMaxRows {
+			j := i + sccMaxRows
+			if j > len(lines) {
+				j = len(lines)
+			}
+
+			seen += utf8.RuneCountInString(strings.Join(lines[i:j], ""))
+			end := cue.End
+			if j < len(lines) && total > 0 {
+				end = cue.Start + cue.Duration()*time.Duration(seen)/time.Duration(total)
+			}
+
+			out = append(out, Cue{Start: start, End: end, Lines: lines[i:j]})
+			start = end
+		}
+	}
+
+	return out
+}
+
+// DecodeSCC reads a Scenarist SCC file and returns the captions shown on
+// CEA-608 channel 1. Each cue is a snapshot of the displayed caption rows
+// between two changes to the display.
+func DecodeSCC(r io.Reader) ([]Cue, error) {
+	scanner := bufio.NewScanner(r)
+
+	header := false
+	dec := &sccDecoder{baseRow: sccDefaultRow, rollRows: 2}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+
+		if !header {
+			if line != sccHeader {
+				return nil, errors.New("missing scc header")
+			}
+			header = true
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		frame, err := parseSCCTimecode(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if frame < dec.frame {
+			return nil, fmt.Errorf("timecode %s is out of order", fields[0])
+		}
+
+		for i, field := range fields[1:] {
+			word, err := strconv.ParseUint(field, 16, 16)
+			if err != nil || len(field) != 4 {
+				return nil, fmt.Errorf("invalid scc word %q", field)
+			}
+			dec.frame = frame + i
+			dec.decode(uint16(word))
+		}
+		dec.frame = frame + len(fields) - 1
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !header {
+		return nil, errors.New("missing scc header")
+	}
+
+	dec.frame++
+	dec.commit()
+
+	return dec.cues, nil
+}
+
+type sccMemory [16][sccColumns]rune
+
+type sccDecoder struct {
+	frame int
+
+	mode      SCCMode
+	displayed sccMemory
+	loading   sccMemory
+	row       int
+	col       int
+	baseRow   int
+	rollRows  int
+
+	lastControl uint16
+
+	cues    []Cue
+	current *Cue
+}
+
+func (d *sccDecoder) memory() *sccMemory {
+	if d.mode == SCCPopOn {
+		return &d.loading
+	}
+	return &d.displayed
+}
+
+func (d *sccDecoder) decode(word uint16) {
+	b1, ok1 := sccStripParity(byte(word >> 8))
+	b2, ok2 := sccStripParity(byte(word))
+	if !ok1 || !ok2 {
+		d.lastControl = 0
+		return
+	}
+
+	if b1 == 0 && b2 == 0 {
+		d.lastControl = 0
+		return
+	}
+
+	if b1 < 0x10 || b1 > 0x1f {
+		d.lastControl = 0
+		d.write(b1)
+		d.write(b2)
+		d.update(false)
+		return
+	}
+
+	code := uint16(b1)<<8 | uint16(b2)
+	if code == d.lastControl {
+		// control codes are sent twice, only the first is acted upon.
+		d.lastControl = 0
+		return
+	}
+	d.lastControl = code
+
+	if b1 > 0x17 {
+		// channel 2 is not decoded.
+		return
+	}
+
+	committed := false
+
+	switch {
+	case b1 == 0x14 && b2 >= 0x20 && b2 <= 0x2f:
+		committed = d.control(code)
+	case b1 == 0x17 && b2 >= 0x21 && b2 <= 0x23:
+		d.col += int(b2 - 0x20)
+		if d.col >= sccColumns {
+			d.col = sccColumns - 1
+		}
+	case b1 == 0x11 && b2 >= 0x30 && b2 <= 0x3f:
+		d.put(sccSpecialChars[b2-0x30])
+	case (b1 == 0x12 || b1 == 0x13) && b2 >= 0x20 && b2 <= 0x3f:
+		if d.col > 0 {
+			d.col--
+		}
+		d.put(sccExtendedChars[int(b1-0x12)*32+int(b2-0x20)])
+	case b1 == 0x11 && b2 >= 0x20 && b2 <= 0x2f:
+		// mid-row codes are displayed as a space.
+		d.put(' ')
+	case b2 >= 0x40 && b2 <= 0x7f:
+		d.pac(b1, b2)
+	}
+
+	d.update(committed)
+}
+
+// control handles a miscellaneous control code and reports whether it
+// replaced the displayed caption.
+func (d *sccDecoder) control(code uint16) bool {
+	switch code {
+	case sccRCL:
+		d.mode = SCCPopOn
+	case sccRDC:
+		d.mode = SCCPaintOn
+	case sccRU2, sccRU3, sccRU4:
+		rows := int(code-sccRU2) + 2
+		wasRollUp := d.mode == SCCRollUp
+		d.mode = SCCRollUp
+		d.rollRows = rows
+		if !wasRollUp {
+			d.displayed = sccMemory{}
+			d.row, d.col = d.baseRow, 0
+			return true
+		}
+	case sccBS:
+		if d.col > 0 {
+			d.col--
+			d.memory()[d.row][d.col] = 0
+		}
+	case sccDER:
+		mem := d.memory()
+		for c := d.col; c < sccColumns; c++ {
+			mem[d.row][c] = 0
+		}
+	case sccEDM:
+		d.displayed = sccMemory{}
+		return true
+	case sccENM:
+		d.loading = sccMemory{}
+	case sccEOC:
+		d.displayed, d.loading = d.loading, d.displayed
+		return true
+	case sccCR:
+		if d.mode != SCCRollUp {
+			return false
+		}
+		top := d.baseRow - d.rollRows + 1
+		for row := 1; row < 16; row++ {
+			switch {
+			case row < top || row > d.baseRow:
+				d.displayed[row] = [sccColumns]rune{}
+			case row < d.baseRow:
+				d.displayed[row] = d.displayed[row+1]
+			default:
+				d.displayed[row] = [sccColumns]rune{}
+			}
+		}
+		d.row, d.col = d.baseRow, 0
+		return true
+	}
+
+	return false
+}
+
+func (d *sccDecoder) pac(b1, b2 byte) {
+	for row, p := range pacRows {
+		if row == 0 || p.b1 != b1 || b2&0x60 != p.base&0x60 {
+			continue
+		}
+
+		if d.mode == SCCRollUp {
+			d.baseRow = row
+		}
+		d.row = row
+		d.col = 0
+		if b2&0x10 != 0 {
+			d.col = int(b2&0x0e) << 1
+		}
+		return
+	}
+}
+
+func (d *sccDecoder) write(b byte) {
+	if b == 0 {
+		return
+	}
+
+	r, ok := sccBasicChars[b]
+	if !ok {
+		r = rune(b)
+	}
+	d.put(r)
+}
+
+func (d *sccDecoder) put(r rune) {
+	if d.row == 0 {
+		d.row = d.baseRow
+	}
+
+	d.memory()[d.row][d.col] = r
+	if d.col < sccColumns-1 {
+		d.col++
+	}
+}
+
+// update records changes to the displayed caption. A commit ends the current
+// cue even if the displayed text did not change.
+func (d *sccDecoder) update(commit bool) {
+	lines := d.displayed.lines()
+
+	if commit || len(lines) == 0 {
+		d.commit()
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	if d.current == nil {
+		d.current = &Cue{Start: sccDuration(d.frame)}
+	}
+	d.current.Lines = lines
+}
+
+func (d *sccDecoder) commit() {
+	if d.current == nil {
+		return
+	}
+
+	d.current.End = sccDuration(d.frame)
+	d.cues = append(d.cues, *d.current)
+	d.current = nil
+}
+
+func (m *sccMemory) lines() []string {
+	var lines []string
+
+	for _, row := range m {
+		var b strings.Builder
+		for _, r := range row {
+			if r == 0 {
+				r = ' '
+			}
+			b.WriteRune(r)
+		}
+
+		if line := strings.TrimSpace(b.String()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// sccSplitPoint returns the closest index to at, without going over it, where
+// words can be split without separating a doubled control code.
+func sccSplitPoint(words []uint16, at int) int {
+	split := 0
+	for i := 0; i < len(words) && i < at; {
+		n := 1
+		if b, _ := sccStripParity(byte(words[i] >> 8)); b >= 0x10 && b <= 0x1f &&
+			i+1 < len(words) && words[i+1] == words[i] {
+			n = 2
+		}
+		if i+n > at {
+			break
+		}
+		i += n
+		split = i
+	}
+	return split
+}
+
+func sccDouble(codes ...uint16) []uint16 {
+	words := make([]uint16, 0, len(codes)*2)
+	for _, code := range codes {
+		words = append(words, sccWord(code), sccWord(code))
+	}
+	return words
+}
+
+func sccWord(code uint16) uint16 {
+	return uint16(sccParity(byte(code>>8)))<<8 | uint16(sccParity(byte(code)))
+}
+
+// sccParity sets the top bit of b so the byte has odd parity.
+func sccParity(b byte) byte {
+	b &= 0x7f
+	ones := 0
+	for v := b; v != 0; v >>= 1 {
+		ones += int(v & 1)
+	}
+	if ones%2 == 0 {
+		b |= 0x80
+	}
+	return b
+}
+
+func sccStripParity(b byte) (byte, bool) {
+	return b & 0x7f, sccParity(b) == b
+}
+
+// sccFrames converts d to a frame count at 29.97fps.
+func sccFrames(d time.Duration) int {
+	return NewTimecode(d, sccFrameRate, TimecodeRoundNearest).Frames
+}
+
+// sccDuration converts a frame count at 29.97fps to a duration.
+func sccDuration(frames int) time.Duration {
+	return Timecode{Frames: frames, Rate: sccFrameRate}.Duration()
+}
+
+// parseSCCTimecode parses a drop-frame or non-drop-frame timecode
+// into a frame count.
+func parseSCCTimecode(s string) (int, error) {
+	rate := FrameRate2997
+	if strings.ContainsAny(s, ";.") {
+		rate = sccFrameRate
+	}
+
+	tc, err := ParseTimecode(s, rate)
+	if err != nil {
+		return 0, err
+	}
+
+	return tc.Frames, nil
+}
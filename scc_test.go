@@ -0,0 +1,181 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSCCCues = []Cue{
+	{Start: 2 * time.Second, End: 4 * time.Second, Lines: []string{"Hello, my name is Jane."}},
+	{Start: 4 * time.Second, End: 6 * time.Second, Lines: []string{"Nice to meet you.", "¿Qué tal? ♪"}},
+	{Start: 8 * time.Second, End: 10 * time.Second, Lines: []string{"Ça va très bien {ok}"}},
+}
+
+func assertSCCTime(t *testing.T, expected, actual time.Duration) {
+	diff := expected - actual
+	if diff < 0 {
+		diff = -diff
+	}
+	assert.LessOrEqual(t, int64(diff), int64(sccDuration(1)), "expected %s got %s", expected, actual)
+}
+
+func TestEncodeSCC_PopOn(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeSCC(buf, testSCCCues, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.True(t, strings.HasPrefix(buf.String(), "Scenarist_SCC V1.0\n\n00:00:01;"))
+	assert.Contains(t, buf.String(), "9420 9420 94ae 94ae")
+
+	cues, err := DecodeSCC(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !assert.Len(t, cues, len(testSCCCues)) {
+		return
+	}
+
+	for i, cue := range cues {
+		assert.Equal(t, testSCCCues[i].Lines, cue.Lines)
+		assertSCCTime(t, testSCCCues[i].Start, cue.Start)
+		assertSCCTime(t, testSCCCues[i].End, cue.End)
+	}
+}
+
+func TestEncodeSCC_PaintOn(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeSCC(buf, testSCCCues, &SCCOptions{Mode: SCCPaintOn}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cues, err := DecodeSCC(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !assert.Len(t, cues, len(testSCCCues)) {
+		return
+	}
+
+	for i, cue := range cues {
+		assert.Equal(t, testSCCCues[i].Lines, cue.Lines)
+		assertSCCTime(t, testSCCCues[i].Start, cue.Start)
+	}
+	assertSCCTime(t, testSCCCues[2].End, cues[2].End)
+}
+
+func TestEncodeSCC_RollUp(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := EncodeSCC(buf, testSCCCues, &SCCOptions{Mode: SCCRollUp, RollUpRows: 3}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cues, err := DecodeSCC(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var lines []string
+	for _, cue := range cues {
+		last := cue.Lines[len(cue.Lines)-1]
+		if len(lines) == 0 || lines[len(lines)-1] != last {
+			lines = append(lines, last)
+		}
+		assert.LessOrEqual(t, len(cue.Lines), 3)
+	}
+
+	assert.Equal(t, []string{"Hello, my name is Jane.", "Nice to meet you.", "¿Qué tal? ♪", "Ça va très bien {ok}"}, lines)
+}
+
+func TestEncodeSCC_Wrap(t *testing.T) {
+	long := Cue{
+		Start: time.Second,
+		End:   5 * time.Second,
+		Lines: []string{strings.Repeat("caption text ", 15)},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := EncodeSCC(buf, []Cue{long}, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cues, err := DecodeSCC(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Len(t, cues, 2)
+	for _, cue := range cues {
+		assert.LessOrEqual(t, len(cue.Lines), 4)
+		for _, line := range cue.Lines {
+			assert.LessOrEqual(t, len(line), 32)
+		}
+	}
+}
+
+func TestSCCTimecode(t *testing.T) {
+	for _, tc := range []string{"00:00:00;00", "00:00:59;29", "00:01:00;02", "01:00:00;00"} {
+		frames, err := parseSCCTimecode(tc)
+		assert.NoError(t, err)
+		assert.Equal(t, tc, Timecode{Frames: frames, Rate: sccFrameRate}.String())
+	}
+
+	frames, err := parseSCCTimecode("00:01:00:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 1800, frames)
+
+	_, err = parseSCCTimecode("00:01:00;00")
+	assert.Error(t, err)
+}
+
+func TestEncodeSCC_StartTimecode(t *testing.T) {
+	start, err := ParseTimecode("01:00:00;00", FrameRate2997DF)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	cues := []Cue{{Start: time.Second, End: 2 * time.Second, Lines: []string{"Hello"}}}
+	if err := EncodeSCC(buf, cues, &SCCOptions{StartTimecode: start}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Contains(t, buf.String(), "\n01:00:0")
+
+	decoded, err := DecodeSCC(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if assert.Len(t, decoded, 1) {
+		assertSCCTime(t, start.Duration()+time.Second, decoded[0].Start)
+	}
+}
+
+func TestSCCParity(t *testing.T) {
+	assert.Equal(t, uint16(0x9420), sccWord(sccRCL))
+	assert.Equal(t, uint16(0x942f), sccWord(sccEOC))
+	assert.Equal(t, uint16(0x94ae), sccWord(sccENM))
+	assert.Equal(t, uint16(0x8080), sccWord(0))
+}
+
+func TestDecodeSCC_MissingHeader(t *testing.T) {
+	_, err := DecodeSCC(strings.NewReader("00:00:00;00\t9420"))
+	assert.Error(t, err)
+}
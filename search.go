@@ -0,0 +1,235 @@
+package revai
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SearchOptions specifies how a transcript is searched.
+type SearchOptions struct {
+	// Normalize is the normalization of both the transcript and the query.
+	// It defaults to StandardNormalization.
+	Normalize *NormalizeOptions
+
+	// Fuzzy is the number of edits a word may be from a query word and
+	// still match. Query words of Fuzzy letters or fewer must match exactly.
+	Fuzzy int
+
+	// Context is the number of words before and after a hit in its snippet.
+	// It defaults to 5, a negative value leaves out the context.
+	Context int
+}
+
+func (o *SearchOptions) withDefaults() SearchOptions {
+	opts := SearchOptions{Normalize: &StandardNormalization, Context: 5}
+
+	if o == nil {
+		return opts
+	}
+
+	if o.Normalize != nil {
+		opts.Normalize = o.Normalize
+	}
+	if o.Fuzzy > 0 {
+		opts.Fuzzy = o.Fuzzy
+	}
+	if o.Context < 0 {
+		opts.Context = 0
+	} else if o.Context > 0 {
+		opts.Context = o.Context
+	}
+
+	return opts
+}
+
+// SearchHit is where a query was found in a transcript. Text, Before and
+// After are the original text of the elements of the hit and its context.
+type SearchHit struct {
+	Speaker      int     `json:"speaker"`
+	Monologue    int     `json:"monologue"`
+	FirstElement int     `json:"first_element"`
+	LastElement  int     `json:"last_element"`
+	Ts           float64 `json:"ts"`
+	EndTs        float64 `json:"end_ts"`
+	Text         string  `json:"text"`
+	Before       string  `json:"before"`
+	After        string  `json:"after"`
+}
+
+// Snippet returns the hit in its context with the hit in brackets.
+func (h SearchHit) Snippet() string {
+	return strings.TrimSpace(h.Before + " [" + h.Text + "] " + h.After)
+}
+
+// Search returns where the words of query were said in a row within a
+// monologue, matching the normalized words of the transcript so a phrase
+// matches across punctuation. Query words may use the wildcards * for any
+// letters and ? for a single letter.
+func (t *Transcript) Search(query string, opts *SearchOptions) []SearchHit {
+	o := opts.withDefaults()
+
+	terms := searchTerms(query, o)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	words := t.Normalize(o.Normalize)
+
+	var hits []SearchHit
+	for i := 0; i+len(terms) <= len(words); i++ {
+		first, last := words[i], words[i+len(terms)-1]
+		if first.Monologue != last.Monologue || !matchTerms(terms, words[i:i+len(terms)]) {
+			continue
+		}
+
+		monologue := t.Monologues[first.Monologue]
+
+		before := 0
+		if k := i - o.Context; k >= 0 && words[k].Monologue == first.Monologue {
+			before = words[k].FirstElement
+		} else {
+			for k := i - 1; k >= 0 && words[k].Monologue == first.Monologue; k-- {
+				before = words[k].FirstElement
+			}
+		}
+
+		after := len(monologue.Elements) - 1
+		if k := i + len(terms) - 1 + o.Context; k < len(words) && words[k].Monologue == first.Monologue {
+			after = words[k].LastElement
+		}
+		if o.Context == 0 {
+			before, after = first.FirstElement, last.LastElement
+		}
+		// the context ends with the punctuation after its last word.
+		for after+1 < len(monologue.Elements) && monologue.Elements[after+1].Type == ElementPunct {
+			after++
+		}
+
+		hits = append(hits, SearchHit{
+			Speaker:      first.Speaker,
+			Monologue:    first.Monologue,
+			FirstElement: first.FirstElement,
+			LastElement:  last.LastElement,
+			Ts:           first.Ts,
+			EndTs:        last.EndTs,
+			Text:         elementsText(monologue.Elements[first.FirstElement : last.LastElement+1]),
+			Before:       elementsText(monologue.Elements[before:first.FirstElement]),
+			After:        elementsText(monologue.Elements[last.LastElement+1 : after+1]),
+		})
+
+		i += len(terms) - 1
+	}
+
+	return hits
+}
+
+// searchTerm matches a normalized word of a transcript.
+type searchTerm func(word string) bool
+
+// searchTerms returns the terms of the words of query. Runs of words without
+// wildcards are normalized together so numbers said in words stay together.
+func searchTerms(query string, o SearchOptions) []searchTerm {
+	var (
+		terms []searchTerm
+		run   []string
+	)
+
+	flush := func() {
+		for _, word := range NormalizeText(strings.Join(run, " "), o.Normalize) {
+			terms = append(terms, fuzzyTerm(word, o.Fuzzy))
+		}
+		run = nil
+	}
+
+	for _, field := range strings.Fields(query) {
+		if !strings.ContainsAny(field, "*?") {
+			run = append(run, field)
+			continue
+		}
+		flush()
+
+		if o.Normalize.Lowercase {
+			field = strings.ToLower(field)
+		}
+		pattern := regexp.QuoteMeta(field)
+		pattern = strings.NewReplacer(`\*`, `.*`, `\?`, `.`).Replace(pattern)
+		re := regexp.MustCompile("^" + pattern + "$")
+		terms = append(terms, re.MatchString)
+	}
+	flush()
+
+	return terms
+}
+
+func fuzzyTerm(term string, edits int) searchTerm {
+	if edits == 0 || len([]rune(term)) <= edits {
+		return func(word string) bool { return word == term }
+	}
+	return func(word string) bool { return editDistance(term, word, edits) <= edits }
+}
+
+func matchTerms(terms []searchTerm, words []NormalizedWord) bool {
+	for i, term := range terms {
+		if !term(words[i].Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// editDistance returns the Levenshtein distance between the runes of a and b,
+// or max+1 once it is known to be more than max.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := prev[j-1]
+			if ra[i-1] != rb[j-1] {
+				cost++
+			}
+			if c := prev[j] + 1; c < cost {
+				cost = c
+			}
+			if c := cur[j-1] + 1; c < cost {
+				cost = c
+			}
+			cur[j] = cost
+			if cost < best {
+				best = cost
+			}
+		}
+		if best > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// elementsText returns the text of elements with whitespace collapsed.
+func elementsText(elements []Element) string {
+	var b strings.Builder
+	for _, element := range elements {
+		if element.Type != ElementPunct && b.Len() > 0 {
+			if r := []rune(b.String()); !unicode.IsSpace(r[len(r)-1]) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(element.Value)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
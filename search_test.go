@@ -0,0 +1,73 @@
+package revai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscript_Search(t *testing.T) {
+	hits := testTranscript.Search("Hello my", nil)
+
+	if !assert.Len(t, hits, 1) {
+		return
+	}
+
+	assert.Equal(t, SearchHit{
+		Speaker:      0,
+		Monologue:    0,
+		FirstElement: 0,
+		LastElement:  3,
+		Ts:           0.5,
+		EndTs:        1.2,
+		Text:         "Hello, my",
+		After:        "name is Jane.",
+	}, hits[0])
+	assert.Equal(t, "[Hello, my] name is Jane.", hits[0].Snippet())
+
+	hits = testTranscript.Search("meet", &SearchOptions{Context: 1})
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, 1, hits[0].Speaker)
+		assert.Equal(t, "to [meet] you.", hits[0].Snippet())
+	}
+
+	// phrases do not cross monologues.
+	assert.Empty(t, testTranscript.Search("jane nice", nil))
+	assert.Empty(t, testTranscript.Search("", nil))
+}
+
+func TestTranscript_Search_Matching(t *testing.T) {
+	transcript := makeTestTranscript("Um, we shipped twenty five orders. We're shipping more, don't worry.")
+
+	tests := []struct {
+		name  string
+		query string
+		opts  *SearchOptions
+		want  []string
+	}{
+		{"word", "we", nil, []string{"we", "We're"}},
+		{"wildcard", "ship*", nil, []string{"shipped", "shipping"}},
+		{"single letter wildcard", "w?", nil, []string{"we", "We're"}},
+		{"fuzzy", "shippin", &SearchOptions{Fuzzy: 1}, []string{"shipping"}},
+		{"fuzzy short word", "wo", &SearchOptions{Fuzzy: 2}, nil},
+		{"contraction", "do not worry", nil, []string{"don't worry"}},
+		{"numbers", "25 orders", &SearchOptions{Normalize: &NormalizeOptions{Lowercase: true, StripPunctuation: true, Numbers: WordsToNumbers}}, []string{"twenty five orders"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var texts []string
+			for _, hit := range transcript.Search(tt.query, tt.opts) {
+				texts = append(texts, hit.Text)
+			}
+			assert.Equal(t, tt.want, texts)
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("jane", "jane", 2))
+	assert.Equal(t, 1, editDistance("jane", "jake", 2))
+	assert.Equal(t, 1, editDistance("jane", "jan", 2))
+	assert.Equal(t, 3, editDistance("jane", "doe", 2))
+}
@@ -0,0 +1,167 @@
+package revai
+
+import (
+	"strings"
+	"time"
+)
+
+// SegmentOptions specifies how a transcript is segmented into sentences and
+// paragraphs.
+type SegmentOptions struct {
+	// SentencePause is the pause between words which ends a sentence without
+	// punctuation. It defaults to 1.5 seconds.
+	SentencePause time.Duration
+
+	// ParagraphPause is the pause between sentences which starts a new
+	// paragraph. It defaults to 3 seconds.
+	ParagraphPause time.Duration
+
+	// MaxParagraphDuration and MaxParagraphSentences limit the length of a
+	// paragraph. They default to a minute and no limit.
+	MaxParagraphDuration  time.Duration
+	MaxParagraphSentences int
+}
+
+func (o *SegmentOptions) withDefaults() SegmentOptions {
+	opts := SegmentOptions{
+		SentencePause:        1500 * time.Millisecond,
+		ParagraphPause:       3 * time.Second,
+		MaxParagraphDuration: time.Minute,
+	}
+
+	if o == nil {
+		return opts
+	}
+
+	if o.SentencePause > 0 {
+		opts.SentencePause = o.SentencePause
+	}
+	if o.ParagraphPause > 0 {
+		opts.ParagraphPause = o.ParagraphPause
+	}
+	if o.MaxParagraphDuration > 0 {
+		opts.MaxParagraphDuration = o.MaxParagraphDuration
+	}
+	if o.MaxParagraphSentences > 0 {
+		opts.MaxParagraphSentences = o.MaxParagraphSentences
+	}
+
+	return opts
+}
+
+// Segment is a sentence or paragraph of a monologue, the elements
+// FirstElement to LastElement. Paragraphs hold their sentences.
+type Segment struct {
+	Speaker      int     `json:"speaker"`
+	Monologue    int     `json:"monologue"`
+	FirstElement int     `json:"first_element"`
+	LastElement  int     `json:"last_element"`
+	Ts           float64 `json:"ts"`
+	EndTs        float64 `json:"end_ts"`
+	Text         string  `json:"text"`
+
+	Sentences []Segment `json:"sentences,omitempty"`
+}
+
+// TimeRange returns the time range of the segment.
+func (s Segment) TimeRange() TimeRange {
+	return TimeRange{Start: secondsToDuration(s.Ts), End: secondsToDuration(s.EndTs)}
+}
+
+// Sentences returns the sentences of the transcript in order. A sentence ends
+// with punctuation ending a sentence or a long pause, and never continues
+// into the next monologue.
+func (t *Transcript) Sentences(opts *SegmentOptions) []Segment {
+	o := opts.withDefaults()
+
+	var sentences []Segment
+	for i, monologue := range t.Monologues {
+		var (
+			current *Segment
+			lastEnd float64
+		)
+		flush := func() {
+			if current != nil {
+				current.Text = elementsText(monologue.Elements[current.FirstElement : current.LastElement+1])
+				sentences = append(sentences, *current)
+			}
+			current = nil
+		}
+
+		for j, element := range monologue.Elements {
+			if element.Type == ElementPunct {
+				if current == nil || strings.TrimSpace(element.Value) == "" {
+					continue
+				}
+				current.LastElement = j
+				if strings.ContainsAny(element.Value, ".?!") {
+					flush()
+				}
+				continue
+			}
+
+			if current != nil && secondsToDuration(element.Ts-lastEnd) >= o.SentencePause {
+				flush()
+			}
+			if current == nil {
+				current = &Segment{Speaker: monologue.Speaker, Monologue: i, FirstElement: j, Ts: element.Ts}
+			}
+			current.LastElement = j
+			current.EndTs = element.EndTs
+			lastEnd = element.EndTs
+		}
+		flush()
+	}
+
+	return sentences
+}
+
+// Paragraphs returns the paragraphs of the transcript in order, each holding
+// its sentences. A new paragraph is started by a new monologue, a long pause
+// or when a paragraph reaches its longest duration or sentences.
+func (t *Transcript) Paragraphs(opts *SegmentOptions) []Segment {
+	o := opts.withDefaults()
+
+	var paragraphs []Segment
+	for _, sentence := range t.Sentences(opts) {
+		if n := len(paragraphs); n > 0 {
+			p := &paragraphs[n-1]
+			continues := p.Monologue == sentence.Monologue &&
+				secondsToDuration(sentence.Ts-p.EndTs) < o.ParagraphPause &&
+				secondsToDuration(sentence.EndTs-p.Ts) <= o.MaxParagraphDuration &&
+				(o.MaxParagraphSentences == 0 || len(p.Sentences) < o.MaxParagraphSentences)
+			if continues {
+				p.LastElement = sentence.LastElement
+				p.EndTs = sentence.EndTs
+				p.Sentences = append(p.Sentences, sentence)
+				continue
+			}
+		}
+
+		p := sentence
+		p.Sentences = []Segment{sentence}
+		paragraphs = append(paragraphs, p)
+	}
+
+	for i, p := range paragraphs {
+		paragraphs[i].Text = elementsText(t.Monologues[p.Monologue].Elements[p.FirstElement : p.LastElement+1])
+	}
+
+	return paragraphs
+}
+
+// Segmented returns a copy of the transcript with a monologue for each
+// segment, so exporters write a cue, annotation or utterance per sentence or
+// paragraph. For example t.Segmented(t.Sentences(nil)).Cues(nil) returns
+// cues which never span two sentences.
+func (t *Transcript) Segmented(segments []Segment) *Transcript {
+	segmented := &Transcript{Monologues: make([]Monologue, len(segments))}
+	for i, s := range segments {
+		elements := t.Monologues[s.Monologue].Elements[s.FirstElement : s.LastElement+1]
+		segmented.Monologues[i] = Monologue{
+			Speaker:  s.Speaker,
+			Elements: append([]Element(nil), elements...),
+		}
+	}
+	return segmented
+}
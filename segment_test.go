@@ -0,0 +1,100 @@
+package revai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// segmentTranscript has a pause of 1.9 seconds after "you?" and 1.8 seconds
+// after "anyway".
+var segmentTranscript = &Transcript{Monologues: []Monologue{
+	{Speaker: 0, Elements: []Element{
+		{Type: "text", Value: "Hello", Ts: 0, EndTs: 0.4},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "there", Ts: 0.4, EndTs: 0.8},
+		{Type: "punct", Value: "."},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "How", Ts: 1.0, EndTs: 1.2},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "are", Ts: 1.2, EndTs: 1.4},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "you", Ts: 1.4, EndTs: 1.6},
+		{Type: "punct", Value: "?"},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "so", Ts: 3.5, EndTs: 3.8},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "anyway", Ts: 3.8, EndTs: 4.2},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "right", Ts: 6.0, EndTs: 6.3},
+		{Type: "punct", Value: "."},
+	}},
+	{Speaker: 1, Elements: []Element{
+		{Type: "text", Value: "Yes", Ts: 7.0, EndTs: 7.3},
+		{Type: "punct", Value: "."},
+	}},
+}}
+
+func TestTranscript_Sentences(t *testing.T) {
+	sentences := segmentTranscript.Sentences(nil)
+
+	// a long pause ends a sentence without punctuation.
+	assert.Equal(t, []Segment{
+		{Speaker: 0, Monologue: 0, FirstElement: 0, LastElement: 3, Ts: 0, EndTs: 0.8, Text: "Hello there."},
+		{Speaker: 0, Monologue: 0, FirstElement: 5, LastElement: 10, Ts: 1.0, EndTs: 1.6, Text: "How are you?"},
+		{Speaker: 0, Monologue: 0, FirstElement: 12, LastElement: 14, Ts: 3.5, EndTs: 4.2, Text: "so anyway"},
+		{Speaker: 0, Monologue: 0, FirstElement: 16, LastElement: 17, Ts: 6.0, EndTs: 6.3, Text: "right."},
+		{Speaker: 1, Monologue: 1, FirstElement: 0, LastElement: 1, Ts: 7.0, EndTs: 7.3, Text: "Yes."},
+	}, sentences)
+
+	assert.Len(t, segmentTranscript.Sentences(&SegmentOptions{SentencePause: 2 * time.Second}), 4)
+	assert.Equal(t, TimeRange{Start: time.Second, End: 1600 * time.Millisecond}, sentences[1].TimeRange())
+	assert.Empty(t, (&Transcript{}).Sentences(nil))
+}
+
+func TestTranscript_Paragraphs(t *testing.T) {
+	paragraphs := segmentTranscript.Paragraphs(nil)
+	if assert.Len(t, paragraphs, 2) {
+		assert.Equal(t, "Hello there. How are you? so anyway right.", paragraphs[0].Text)
+		assert.Equal(t, 0, paragraphs[0].FirstElement)
+		assert.Equal(t, 17, paragraphs[0].LastElement)
+		assert.Equal(t, 6.3, paragraphs[0].EndTs)
+		assert.Len(t, paragraphs[0].Sentences, 4)
+
+		// a new monologue starts a new paragraph.
+		assert.Equal(t, 1, paragraphs[1].Speaker)
+		assert.Equal(t, "Yes.", paragraphs[1].Text)
+	}
+
+	texts := func(paragraphs []Segment) []string {
+		var texts []string
+		for _, p := range paragraphs {
+			texts = append(texts, p.Text)
+		}
+		return texts
+	}
+
+	assert.Equal(t, []string{"Hello there. How are you?", "so anyway", "right.", "Yes."},
+		texts(segmentTranscript.Paragraphs(&SegmentOptions{ParagraphPause: 1800 * time.Millisecond})))
+	assert.Equal(t, []string{"Hello there. How are you?", "so anyway right.", "Yes."},
+		texts(segmentTranscript.Paragraphs(&SegmentOptions{MaxParagraphSentences: 2})))
+	assert.Equal(t, []string{"Hello there. How are you? so anyway", "right.", "Yes."},
+		texts(segmentTranscript.Paragraphs(&SegmentOptions{MaxParagraphDuration: 5 * time.Second})))
+}
+
+func TestTranscript_Segmented(t *testing.T) {
+	segmented := segmentTranscript.Segmented(segmentTranscript.Sentences(nil))
+
+	if assert.Len(t, segmented.Monologues, 5) {
+		assert.Equal(t, segmentTranscript.Monologues[0].Elements[5:11], segmented.Monologues[1].Elements)
+		assert.Equal(t, 1, segmented.Monologues[4].Speaker)
+	}
+
+	// exporters write a cue per sentence.
+	cues := segmented.Cues(nil)
+	if assert.Len(t, cues, 5) {
+		assert.Equal(t, "How are you?", cues[1].Text())
+		assert.Equal(t, time.Second, cues[1].Start)
+	}
+}
@@ -0,0 +1,188 @@
+package revai
+
+import (
+	"strings"
+	"time"
+)
+
+// BoundaryPolicy is how Slice treats elements crossing the start or end of
+// the range.
+type BoundaryPolicy int
+
+const (
+	// BoundaryInclude keeps elements overlapping the range.
+	BoundaryInclude BoundaryPolicy = iota
+	// BoundaryExclude keeps only elements within the range.
+	BoundaryExclude
+	// BoundaryTrim keeps elements overlapping the range with their timing
+	// trimmed to it.
+	BoundaryTrim
+	// BoundaryStart keeps elements starting within the range, so slices of
+	// adjacent ranges never share an element.
+	BoundaryStart
+)
+
+// SliceOptions specifies how a transcript is sliced.
+type SliceOptions struct {
+	Boundary BoundaryPolicy
+}
+
+// Slice returns a copy of the transcript with the elements between start and
+// end. Timestamps are not changed other than by BoundaryTrim, punctuation is
+// kept with the word before it and monologues without words are left out.
+func (t *Transcript) Slice(start, end time.Duration, opts *SliceOptions) *Transcript {
+	if opts == nil {
+		opts = &SliceOptions{}
+	}
+
+	from, to := start.Seconds(), end.Seconds()
+
+	sliced := &Transcript{}
+	for _, monologue := range t.Monologues {
+		var (
+			elements []Element
+			kept     bool
+			words    int
+		)
+		for _, element := range monologue.Elements {
+			if !timed(element) {
+				if kept && len(elements) > 0 {
+					elements = append(elements, element)
+				}
+				continue
+			}
+
+			overlaps := element.EndTs > from && element.Ts < to || element.Ts == element.EndTs && element.Ts >= from && element.Ts < to
+			within := element.Ts >= from && element.EndTs <= to
+
+			switch opts.Boundary {
+			case BoundaryExclude:
+				kept = within
+			case BoundaryStart:
+				kept = element.Ts >= from && element.Ts < to
+			default:
+				kept = overlaps
+			}
+			if !kept {
+				continue
+			}
+
+			if opts.Boundary == BoundaryTrim && !within {
+				if element.Ts < from {
+					element.Ts = from
+				}
+				if element.EndTs > to {
+					element.EndTs = to
+				}
+			}
+			elements = append(elements, element)
+			if element.Type == ElementText {
+				words++
+			}
+		}
+
+		// whitespace after the last word is left out.
+		for len(elements) > 0 && !timed(elements[len(elements)-1]) && strings.TrimSpace(elements[len(elements)-1].Value) == "" {
+			elements = elements[:len(elements)-1]
+		}
+
+		if words > 0 {
+			sliced.Monologues = append(sliced.Monologues, Monologue{Speaker: monologue.Speaker, Elements: elements})
+		}
+	}
+
+	return sliced
+}
+
+// Shift returns a copy of the transcript with the timestamps of its elements
+// moved by offset. Timestamps are shifted as durations so whole milliseconds
+// stay exact.
+func (t *Transcript) Shift(offset time.Duration) *Transcript {
+	shifted := &Transcript{Monologues: make([]Monologue, len(t.Monologues))}
+	for i, monologue := range t.Monologues {
+		elements := make([]Element, len(monologue.Elements))
+		for j, element := range monologue.Elements {
+			if timed(element) {
+				element.Ts = (secondsToDuration(element.Ts) + offset).Seconds()
+				element.EndTs = (secondsToDuration(element.EndTs) + offset).Seconds()
+			}
+			elements[j] = element
+		}
+		shifted.Monologues[i] = Monologue{Speaker: monologue.Speaker, Elements: elements}
+	}
+	return shifted
+}
+
+// TranscriptPart is a transcript joined by Concat.
+type TranscriptPart struct {
+	Transcript *Transcript
+
+	// Offset is the time the transcript is shifted by.
+	Offset time.Duration
+
+	// Speakers maps the speakers of the transcript to speakers of the joined
+	// transcript. When nil speakers keep their numbers, otherwise speakers
+	// missing from it are given new numbers after the highest speaker used.
+	Speakers map[int]int
+}
+
+// Concat returns the transcripts of parts joined in order. A monologue
+// continuing the last monologue of the part before it with the same speaker
+// is merged with it.
+func Concat(parts ...TranscriptPart) *Transcript {
+	// speakers used by the parts, so new speakers do not reuse them.
+	next := 0
+	use := func(speaker int) {
+		if speaker >= next {
+			next = speaker + 1
+		}
+	}
+	for _, part := range parts {
+		for _, speaker := range part.Speakers {
+			use(speaker)
+		}
+		if part.Speakers == nil {
+			for _, monologue := range part.Transcript.Monologues {
+				use(monologue.Speaker)
+			}
+		}
+	}
+
+	joined := &Transcript{}
+	for _, part := range parts {
+		assigned := map[int]int{}
+		speaker := func(s int) int {
+			if part.Speakers == nil {
+				return s
+			}
+			if mapped, ok := part.Speakers[s]; ok {
+				return mapped
+			}
+			if _, ok := assigned[s]; !ok {
+				assigned[s] = next
+				next++
+			}
+			return assigned[s]
+		}
+
+		for i, monologue := range part.Transcript.Shift(part.Offset).Monologues {
+			monologue.Speaker = speaker(monologue.Speaker)
+
+			if n := len(joined.Monologues); i == 0 && n > 0 && joined.Monologues[n-1].Speaker == monologue.Speaker {
+				last := &joined.Monologues[n-1]
+				last.Elements = append(last.Elements, Element{Type: ElementPunct, Value: " "})
+				last.Elements = append(last.Elements, monologue.Elements...)
+				continue
+			}
+			joined.Monologues = append(joined.Monologues, monologue)
+		}
+	}
+
+	return joined
+}
+
+// timed reports whether an element has timestamps, which punctuation does
+// not.
+func timed(element Element) bool {
+	return element.Type != ElementPunct || element.Ts != 0 || element.EndTs != 0
+}
@@ -0,0 +1,94 @@
+package revai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscript_Slice(t *testing.T) {
+	// "my" is 1.0-1.2, "name" 1.2-1.5, "is" 1.5-1.7 and "Jane" 1.7-2.1.
+	tests := []struct {
+		name     string
+		boundary BoundaryPolicy
+		want     string
+	}{
+		{"include", BoundaryInclude, "my name is Jane."},
+		{"exclude", BoundaryExclude, "name is"},
+		{"trim", BoundaryTrim, "my name is Jane."},
+		{"start", BoundaryStart, "name is Jane."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sliced := testTranscript.Slice(1100*time.Millisecond, 2*time.Second, &SliceOptions{Boundary: tt.boundary})
+			assert.Equal(t, tt.want, transcriptText(sliced))
+		})
+	}
+
+	trimmed := testTranscript.Slice(1100*time.Millisecond, 2*time.Second, &SliceOptions{Boundary: BoundaryTrim})
+	elements := trimmed.Monologues[0].Elements
+	assert.Equal(t, Element{Type: "text", Value: "my", Ts: 1.1, EndTs: 1.2, Confidence: 0.9}, elements[0])
+	assert.Equal(t, 1.2, elements[2].Ts)
+	assert.Equal(t, 2.0, elements[len(elements)-2].EndTs)
+
+	// timestamps are not changed and monologues without words are left out.
+	included := testTranscript.Slice(1100*time.Millisecond, 2*time.Second, nil)
+	assert.Len(t, included.Monologues, 1)
+	assert.Equal(t, testTranscript.Monologues[0].Elements[3:], included.Monologues[0].Elements)
+
+	both := testTranscript.Slice(2*time.Second, 2900*time.Millisecond, nil)
+	assert.Equal(t, "Jane.\nNice to", transcriptText(both))
+	assert.Equal(t, 1, both.Monologues[1].Speaker)
+
+	assert.Empty(t, testTranscript.Slice(10*time.Second, 20*time.Second, nil).Monologues)
+}
+
+func TestTranscript_Shift(t *testing.T) {
+	transcript := makeTestTranscript("one two.")
+
+	shifted := transcript.Shift(100 * time.Millisecond)
+
+	assert.Equal(t, []Element{
+		{Type: "text", Value: "one", Ts: 0.1, EndTs: 0.4, Confidence: 1},
+		{Type: "punct", Value: " "},
+		{Type: "text", Value: "two", Ts: 0.4, EndTs: 0.7, Confidence: 1},
+		{Type: "punct", Value: "."},
+	}, shifted.Monologues[0].Elements)
+
+	// the original is unchanged and shifting back is exact.
+	assert.Equal(t, 0.0, transcript.Monologues[0].Elements[0].Ts)
+	assert.Equal(t, testTranscript, testTranscript.Shift(time.Hour+time.Millisecond).Shift(-time.Hour-time.Millisecond))
+}
+
+func TestConcat(t *testing.T) {
+	first := makeTestTranscript("hello there")
+	first.Monologues = append(first.Monologues, Monologue{Speaker: 1, Elements: []Element{{Type: "text", Value: "hi", Ts: 1, EndTs: 1.2}}})
+
+	second := makeTestTranscript("how are you")
+	second.Monologues[0].Speaker = 1
+
+	joined := Concat(
+		TranscriptPart{Transcript: first},
+		TranscriptPart{Transcript: second, Offset: 10 * time.Second},
+	)
+
+	// the second part continues speaker 1 so the monologues are merged.
+	assert.Equal(t, "hello there\nhi how are you", transcriptText(joined))
+	assert.Equal(t, 10.3, joined.Monologues[1].Elements[4].Ts)
+
+	mapped := Concat(
+		TranscriptPart{Transcript: first, Speakers: map[int]int{0: 5}},
+		TranscriptPart{Transcript: second, Speakers: map[int]int{}, Offset: 10 * time.Second},
+	)
+
+	if assert.Len(t, mapped.Monologues, 3) {
+		// unmapped speakers are given numbers after the highest used.
+		assert.Equal(t, 5, mapped.Monologues[0].Speaker)
+		assert.Equal(t, 6, mapped.Monologues[1].Speaker)
+		assert.Equal(t, 7, mapped.Monologues[2].Speaker)
+	}
+
+	assert.Empty(t, Concat().Monologues)
+}
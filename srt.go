@@ -0,0 +1,76 @@
+package revai
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DecodeSRT parses a SubRip caption file. The cue index of each cue is kept as its ID.
+func DecodeSRT(r io.Reader) (*CaptionFile, error) {
+	blocks, err := splitCaptionBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &CaptionFile{Format: CaptionFormatSRT}
+
+	for _, block := range blocks {
+		var id string
+		if !strings.Contains(block[0], "-->") {
+			id = strings.TrimSpace(block[0])
+			block = block[1:]
+		}
+
+		if len(block) == 0 {
+			return nil, fmt.Errorf("srt cue %q is missing its timing", id)
+		}
+
+		cue, err := parseCueTiming(block[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing srt cue %q %w", id, err)
+		}
+
+		cue.ID = id
+		cue.Lines = block[1:]
+
+		f.Cues = append(f.Cues, cue)
+	}
+
+	return f, nil
+}
+
+// EncodeSRT writes cues to w as a SubRip caption file. Cues are numbered by
+// their ID when it is a number, otherwise by their position.
+// Cue settings are not part of SubRip and are not written.
+func EncodeSRT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+
+	for i, cue := range cues {
+		id := cue.ID
+		if _, err := strconv.Atoi(id); err != nil {
+			id = strconv.Itoa(i + 1)
+		}
+
+		if i > 0 {
+			if _, err := bw.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(bw, "%s\n%s --> %s\n", id,
+			formatCueTimestamp(cue.Start, ","), formatCueTimestamp(cue.End, ",")); err != nil {
+			return err
+		}
+
+		for _, line := range cue.Lines {
+			if _, err := bw.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,56 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSRT = `1
+00:00:00,500 --> 00:00:02,100
+Hello, my name is <i>Jane</i>.
+
+2
+00:00:02,500 --> 00:00:03,400
+Nice to meet you.
+Q&A starts now.
+`
+
+func TestDecodeSRT(t *testing.T) {
+	f, err := DecodeSRT(strings.NewReader(strings.ReplaceAll(testSRT, "\n", "\r\n")))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, CaptionFormatSRT, f.Format)
+	assert.Equal(t, []Cue{
+		{ID: "1", Start: 500 * time.Millisecond, End: 2100 * time.Millisecond, Lines: []string{"Hello, my name is <i>Jane</i>."}},
+		{ID: "2", Start: 2500 * time.Millisecond, End: 3400 * time.Millisecond, Lines: []string{"Nice to meet you.", "Q&A starts now."}},
+	}, f.Cues)
+	assert.Equal(t, "Hello, my name is Jane.", f.Cues[0].PlainText())
+}
+
+func TestEncodeSRT(t *testing.T) {
+	f, err := DecodeSRT(strings.NewReader(testSRT))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := EncodeSRT(buf, f.Cues); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, testSRT, buf.String())
+}
+
+func TestDecodeSRT_InvalidTiming(t *testing.T) {
+	_, err := DecodeSRT(strings.NewReader("1\n00:00:01,000 -> 00:00:02,000\nhello\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package revai
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// stitchTolerance is how far apart in time the same word may be in the
+// transcripts of overlapping chunks.
+const stitchTolerance = time.Second
+
+// StitchPart is the transcript of a chunk of media joined by Stitch.
+type StitchPart struct {
+	Transcript *Transcript
+
+	// Offset is the start of the chunk in the media.
+	Offset time.Duration
+
+	// Duration is the length of the chunk. When zero the chunk is taken to
+	// end with its last word.
+	Duration time.Duration
+}
+
+// Stitch joins the transcripts of overlapping chunks of media in order.
+// Words said in the overlap of two chunks are matched, and the transcripts
+// are cut at the matched word closest to the middle of the overlap so no word
+// is lost or repeated. Speakers of a chunk are given the speaker of the chunk
+// before it they share the most matched words with, and new speakers are
+// given numbers after the highest used.
+func Stitch(parts []StitchPart) *Transcript {
+	if len(parts) == 0 {
+		return &Transcript{}
+	}
+
+	shifted := make([]*Transcript, len(parts))
+	for i, part := range parts {
+		shifted[i] = part.Transcript.Shift(part.Offset)
+	}
+
+	// the first part keeps its speakers.
+	speakers := make([]map[int]int, len(parts))
+	speakers[0] = map[int]int{}
+	next := 0
+	for _, monologue := range shifted[0].Monologues {
+		speakers[0][monologue.Speaker] = monologue.Speaker
+		if monologue.Speaker >= next {
+			next = monologue.Speaker + 1
+		}
+	}
+
+	// cuts[i] is where part i ends and part i+1 starts.
+	cuts := make([][2]time.Duration, len(parts)-1)
+	for i := 1; i < len(parts); i++ {
+		prev, cur := shifted[i-1], shifted[i]
+
+		overlapStart := parts[i].Offset
+		overlapEnd := parts[i-1].Offset + parts[i-1].Duration
+		if parts[i-1].Duration == 0 {
+			overlapEnd = lastWordEnd(prev)
+		}
+
+		matches := matchOverlap(prev, cur, overlapStart, overlapEnd)
+
+		middle := (overlapStart + overlapEnd) / 2
+		cuts[i-1] = [2]time.Duration{middle, middle}
+		if len(matches) > 0 {
+			best := matches[0]
+			for _, m := range matches[1:] {
+				if absDuration(secondsToDuration(m.prev.Ts)-middle) < absDuration(secondsToDuration(best.prev.Ts)-middle) {
+					best = m
+				}
+			}
+			cuts[i-1] = [2]time.Duration{secondsToDuration(best.prev.Ts), secondsToDuration(best.cur.Ts)}
+		}
+
+		speakers[i] = reconcileSpeakers(matches, speakers[i-1])
+		for _, monologue := range cur.Monologues {
+			if _, ok := speakers[i][monologue.Speaker]; !ok {
+				speakers[i][monologue.Speaker] = next
+				next++
+			}
+		}
+	}
+
+	joined := make([]TranscriptPart, len(parts))
+	for i := range parts {
+		start, end := time.Duration(math.MinInt64), time.Duration(math.MaxInt64)
+		if i > 0 {
+			start = cuts[i-1][1]
+		}
+		if i < len(cuts) {
+			end = cuts[i][0]
+		}
+		joined[i] = TranscriptPart{Transcript: shifted[i].Slice(start, end, &SliceOptions{Boundary: BoundaryStart}), Speakers: speakers[i]}
+	}
+
+	return Concat(joined...)
+}
+
+// overlapMatch is a word said in the overlap of two chunks.
+type overlapMatch struct {
+	prev, cur NormalizedWord
+}
+
+// matchOverlap returns the words of the overlap matched in both transcripts,
+// the longest common subsequence of words within stitchTolerance of each
+// other.
+func matchOverlap(prev, cur *Transcript, start, end time.Duration) []overlapMatch {
+	within := func(t *Transcript) []NormalizedWord {
+		var words []NormalizedWord
+		for _, w := range t.Normalize(nil) {
+			ts := secondsToDuration(w.Ts)
+			if ts >= start-stitchTolerance && ts <= end+stitchTolerance {
+				words = append(words, w)
+			}
+		}
+		return words
+	}
+
+	a, b := within(prev), within(cur)
+	match := func(i, j int) bool {
+		return a[i].Text == b[j].Text && absDuration(secondsToDuration(a[i].Ts-b[j].Ts)) <= stitchTolerance
+	}
+
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case match(i, j):
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var matches []overlapMatch
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case match(i, j):
+			matches = append(matches, overlapMatch{a[i], b[j]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// reconcileSpeakers maps the speakers of the current chunk to the speakers of
+// the chunk before it they share the most matched words with.
+func reconcileSpeakers(matches []overlapMatch, prevSpeakers map[int]int) map[int]int {
+	type pair struct{ cur, prev int }
+
+	counts := map[pair]int{}
+	for _, m := range matches {
+		counts[pair{m.cur.Speaker, prevSpeakers[m.prev.Speaker]}]++
+	}
+
+	pairs := make([]pair, 0, len(counts))
+	for p := range counts {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if counts[pairs[i]] != counts[pairs[j]] {
+			return counts[pairs[i]] > counts[pairs[j]]
+		}
+		if pairs[i].cur != pairs[j].cur {
+			return pairs[i].cur < pairs[j].cur
+		}
+		return pairs[i].prev < pairs[j].prev
+	})
+
+	mapped := map[int]int{}
+	used := map[int]bool{}
+	for _, p := range pairs {
+		if _, ok := mapped[p.cur]; ok || used[p.prev] {
+			continue
+		}
+		mapped[p.cur] = p.prev
+		used[p.prev] = true
+	}
+	return mapped
+}
+
+func lastWordEnd(t *Transcript) time.Duration {
+	var end time.Duration
+	for _, monologue := range t.Monologues {
+		if r := monologue.TimeRange(); r.End > end {
+			end = r.End
+		}
+	}
+	return end
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,88 @@
+package revai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// makeConversation returns a transcript of speakers taking turns saying
+// numbered words, each 0.3 seconds long.
+func makeConversation(turns, words int) *Transcript {
+	transcript := &Transcript{}
+	ts := 0.0
+	for i := 0; i < turns; i++ {
+		monologue := Monologue{Speaker: i % 2}
+		for j := 0; j < words; j++ {
+			if j > 0 {
+				monologue.Elements = append(monologue.Elements, Element{Type: "punct", Value: " "})
+			}
+			end := float64(secondsToDuration(ts)+300*time.Millisecond) / float64(time.Second)
+			monologue.Elements = append(monologue.Elements, Element{Type: "text", Value: fmt.Sprintf("w%d", i*words+j), Ts: ts, EndTs: end, Confidence: 1})
+			ts = end
+		}
+		monologue.Elements = append(monologue.Elements, Element{Type: "punct", Value: "."})
+		transcript.Monologues = append(transcript.Monologues, monologue)
+	}
+	return transcript
+}
+
+// chunkOf returns the transcript of the chunk of media from start to end with
+// its speakers renumbered.
+func chunkOf(transcript *Transcript, start, end time.Duration, speakers map[int]int) StitchPart {
+	chunk := transcript.Slice(start, end, nil).Shift(-start)
+	for i := range chunk.Monologues {
+		if s, ok := speakers[chunk.Monologues[i].Speaker]; ok {
+			chunk.Monologues[i].Speaker = s
+		}
+	}
+	return StitchPart{Transcript: chunk, Offset: start, Duration: end - start}
+}
+
+func TestStitch(t *testing.T) {
+	// speaker 0 then 1 then 0, 6 seconds each.
+	full := makeConversation(3, 20)
+
+	stitched := Stitch([]StitchPart{
+		chunkOf(full, 0, 8*time.Second, nil),
+		// the second chunk numbers its speakers the other way around.
+		chunkOf(full, 5*time.Second, 14*time.Second, map[int]int{0: 1, 1: 0}),
+		chunkOf(full, 11*time.Second, 18*time.Second, nil),
+	})
+
+	// overlapping words are not repeated and speakers are reconciled.
+	assert.Equal(t, transcriptText(full), transcriptText(stitched))
+	if assert.Len(t, stitched.Monologues, 3) {
+		for i, monologue := range stitched.Monologues {
+			assert.Equal(t, i%2, monologue.Speaker)
+			assert.Equal(t, full.Monologues[i].TimeRange(), monologue.TimeRange())
+		}
+	}
+}
+
+func TestStitch_NoMatches(t *testing.T) {
+	full := makeConversation(1, 20)
+
+	// the second chunk misheard the overlap, so the transcripts are cut in its
+	// middle and the new speaker is given a new number.
+	second := chunkOf(full, 3*time.Second, 6*time.Second, map[int]int{0: 3})
+	for i, element := range second.Transcript.Monologues[0].Elements {
+		if element.Type == "text" && element.Ts < 1 {
+			second.Transcript.Monologues[0].Elements[i].Value = "mumble"
+		}
+	}
+
+	stitched := Stitch([]StitchPart{chunkOf(full, 0, 4*time.Second, nil), second})
+
+	if assert.Len(t, stitched.Monologues, 2) {
+		assert.Equal(t, 0, stitched.Monologues[0].Speaker)
+		assert.Equal(t, 1, stitched.Monologues[1].Speaker)
+		assert.Equal(t, 3.6, stitched.Monologues[1].Elements[0].Ts)
+		assert.True(t, strings.HasPrefix(transcriptText(stitched), "w0 w1"))
+	}
+
+	assert.Empty(t, Stitch(nil).Monologues)
+}
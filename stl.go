@@ -0,0 +1,619 @@
+package revai
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stlGSISize       = 1024
+	stlTTISize       = 128
+	stlTextFieldSize = 112
+	stlMaxChars      = 40
+	stlMaxRows       = 23
+	stlMaxSubtitles  = 65535
+)
+
+// EBU-STL text field control codes.
+const (
+	stlDoubleHeight = 0x0d
+	stlStartBox     = 0x0b
+	stlEndBox       = 0x0a
+	stlNewLine      = 0x8a
+	stlUnused       = 0x8f
+	stlLastBlock    = 0xff
+	stlUserData     = 0xfe
+)
+
+// STLDisplayStandard is the display standard code (DSC) of an EBU-STL file.
+type STLDisplayStandard byte
+
+const (
+	STLUndefined      STLDisplayStandard = ' '
+	STLOpenSubtitling STLDisplayStandard = '0'
+	STLTeletextLevel1 STLDisplayStandard = '1'
+	STLTeletextLevel2 STLDisplayStandard = '2'
+)
+
+// STLCharacterCodeTable is the character code table (CCT) used for the
+// text fields of an EBU-STL file.
+type STLCharacterCodeTable string
+
+const (
+	STLLatin    STLCharacterCodeTable = "00"
+	STLCyrillic STLCharacterCodeTable = "01"
+	STLArabic   STLCharacterCodeTable = "02"
+	STLGreek    STLCharacterCodeTable = "03"
+	STLHebrew   STLCharacterCodeTable = "04"
+)
+
+// STLOptions specifies the general subtitle information written by EncodeSTL.
+// DecodeSTL returns the options read from a file.
+type STLOptions struct {
+	// FrameRate is FrameRate25 or another non-drop-frame rate
+	// counted in 30 frames per second such as FrameRate30.
+	FrameRate          FrameRate
+	CharacterCodeTable STLCharacterCodeTable
+	DisplayStandard    STLDisplayStandard
+
+	// LanguageCode is the two character EBU language code, for example "09" for English.
+	LanguageCode    string
+	ProgrammeTitle  string
+	EpisodeTitle    string
+	CountryOfOrigin string
+	Publisher       string
+	CreationDate    time.Time
+
+	// StartTimecode is the timecode of the start of the programme.
+	// It is added to the cue timings.
+	StartTimecode Timecode
+}
+
+func (o *STLOptions) withDefaults() STLOptions {
+	opts := STLOptions{
+		FrameRate:          FrameRate25,
+		CharacterCodeTable: STLLatin,
+		DisplayStandard:    STLTeletextLevel1,
+		LanguageCode:       "09",
+		CountryOfOrigin:    "USA",
+		CreationDate:       time.Now(),
+	}
+
+	if o == nil {
+		return opts
+	}
+
+	if !o.FrameRate.IsZero() {
+		opts.FrameRate = o.FrameRate
+	}
+	if o.CharacterCodeTable != "" {
+		opts.CharacterCodeTable = o.CharacterCodeTable
+	}
+	if o.DisplayStandard != 0 {
+		opts.DisplayStandard = o.DisplayStandard
+	}
+	if o.LanguageCode != "" {
+		opts.LanguageCode = o.LanguageCode
+	}
+	if o.CountryOfOrigin != "" {
+		opts.CountryOfOrigin = o.CountryOfOrigin
+	}
+	if !o.CreationDate.IsZero() {
+		opts.CreationDate = o.CreationDate
+	}
+	opts.ProgrammeTitle = o.ProgrammeTitle
+	opts.EpisodeTitle = o.EpisodeTitle
+	opts.Publisher = o.Publisher
+	opts.StartTimecode = o.StartTimecode
+
+	return opts
+}
+
+func (o STLOptions) teletext() bool {
+	return o.DisplayStandard == STLTeletextLevel1 || o.DisplayStandard == STLTeletextLevel2
+}
+
+// EncodeSTL writes cues to w as an EBU Tech 3264 STL file made up of a GSI
+// header block followed by one or more TTI blocks per cue. Lines longer than
+// 40 characters are wrapped, and at most 65535 cues can be numbered.
+func EncodeSTL(w io.Writer, cues []Cue, opts *STLOptions) error {
+	o := opts.withDefaults()
+
+	if tb := o.FrameRate.timebase(); (tb != 25 && tb != 30) || o.FrameRate.DropFrame {
+		return fmt.Errorf("unsupported stl frame rate %s", o.FrameRate)
+	}
+
+	charset, ok := stlCharsets[o.CharacterCodeTable]
+	if !ok {
+		return fmt.Errorf("unsupported stl character code table %q", o.CharacterCodeTable)
+	}
+
+	if len(cues) > stlMaxSubtitles {
+		return fmt.Errorf("too many stl subtitles %d, at most %d", len(cues), stlMaxSubtitles)
+	}
+
+	var blocks [][]byte
+	for i, cue := range cues {
+		if cue.End <= cue.Start {
+			return fmt.Errorf("cue %d ends before it starts", i)
+		}
+
+		var lines []string
+		for _, line := range cue.PlainLines() {
+			lines = append(lines, wrapText(line, stlMaxChars)...)
+		}
+		text := o.encodeText(charset, lines)
+
+		// text that does not fit in a single block continues in extension blocks.
+		for ebn := 0; ; ebn++ {
+			n := len(text)
+			if n > stlTextFieldSize {
+				n = stlTextFieldSize
+			}
+
+			tti := make([]byte, stlTTISize)
+			binary.LittleEndian.PutUint16(tti[1:3], uint16(i+1))
+			tti[3] = byte(ebn)
+			if n == len(text) {
+				tti[3] = stlLastBlock
+			}
+			copy(tti[5:9], stlTimecode(o.timecode(cue.Start)))
+			copy(tti[9:13], stlTimecode(o.timecode(cue.End)))
+			tti[13] = o.verticalPosition(len(lines))
+			tti[14] = 2 // centered
+			copy(tti[16:], text[:n])
+			for j := 16 + n; j < stlTTISize; j++ {
+				tti[j] = stlUnused
+			}
+
+			blocks = append(blocks, tti)
+			text = text[n:]
+			if len(text) == 0 {
+				break
+			}
+		}
+	}
+
+	if len(blocks) > 99999 {
+		return errors.New("too many stl blocks")
+	}
+
+	var firstCue time.Duration
+	if len(cues) > 0 {
+		firstCue = cues[0].Start
+	}
+
+	gsi := o.gsi(len(blocks), len(cues), firstCue)
+	if _, err := w.Write(gsi); err != nil {
+		return err
+	}
+
+	for _, tti := range blocks {
+		if _, err := w.Write(tti); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o STLOptions) gsi(blocks, subtitles int, firstCue time.Duration) []byte {
+	gsi := bytes.Repeat([]byte{' '}, stlGSISize)
+
+	field := func(offset, size int, value string) {
+		b := []byte(stlASCII(value))
+		if len(b) > size {
+			b = b[:size]
+		}
+		copy(gsi[offset:offset+size], b)
+	}
+
+	tc := func(tc Timecode) string {
+		b := stlTimecode(tc)
+		return fmt.Sprintf("%02d%02d%02d%02d", b[0], b[1], b[2], b[3])
+	}
+
+	date := o.CreationDate.Format("060102")
+
+	field(0, 3, "850")
+	field(3, 8, fmt.Sprintf("STL%d.01", o.FrameRate.timebase()))
+	gsi[11] = byte(o.DisplayStandard)
+	field(12, 2, string(o.CharacterCodeTable))
+	field(14, 2, o.LanguageCode)
+	field(16, 32, o.ProgrammeTitle)
+	field(48, 32, o.EpisodeTitle)
+	field(224, 6, date)
+	field(230, 6, date)
+	field(236, 2, "00")
+	field(238, 5, fmt.Sprintf("%05d", blocks))
+	field(243, 5, fmt.Sprintf("%05d", subtitles))
+	field(248, 3, "001")
+	field(251, 2, strconv.Itoa(stlMaxChars))
+	field(253, 2, strconv.Itoa(stlMaxRows))
+	gsi[255] = '1'
+	field(256, 8, tc(o.timecode(0)))
+	field(264, 8, tc(o.timecode(firstCue)))
+	gsi[272] = '1'
+	gsi[273] = '1'
+	field(274, 3, o.CountryOfOrigin)
+	field(277, 32, o.Publisher)
+
+	return gsi
+}
+
+// encodeText encodes lines for a TTI text field. Teletext subtitles are
+// displayed boxed and in double height.
+func (o STLOptions) encodeText(charset stlCharset, lines []string) []byte {
+	var text []byte
+
+	for i, line := range lines {
+		if i > 0 {
+			text = append(text, stlNewLine)
+			if o.teletext() {
+				text = append(text, stlNewLine)
+			}
+		}
+
+		if o.teletext() {
+			text = append(text, stlDoubleHeight, stlStartBox, stlStartBox)
+		}
+
+		for _, r := range line {
+			text = append(text, charset.encode(r)...)
+		}
+
+		if o.teletext() {
+			text = append(text, stlEndBox, stlEndBox)
+		}
+	}
+
+	return text
+}
+
+// verticalPosition places the last line of a subtitle at the bottom of the screen.
+func (o STLOptions) verticalPosition(lines int) byte {
+	if lines < 1 {
+		lines = 1
+	}
+
+	step := 1
+	if o.teletext() {
+		step = 2
+	}
+
+	vp := stlMaxRows - 1 - (lines-1)*step
+	if vp < 1 {
+		vp = 1
+	}
+
+	return byte(vp)
+}
+
+// DecodeSTL reads an EBU Tech 3264 STL file and returns its cues along with
+// the general subtitle information from the GSI block.
+// Comment and user data blocks are skipped.
+func DecodeSTL(r io.Reader) ([]Cue, *STLOptions, error) {
+	gsi := make([]byte, stlGSISize)
+	if _, err := io.ReadFull(r, gsi); err != nil {
+		return nil, nil, fmt.Errorf("failed reading gsi block %w", err)
+	}
+
+	dfc := string(gsi[3:11])
+	if !strings.HasPrefix(dfc, "STL") || !strings.HasSuffix(dfc, ".01") {
+		return nil, nil, fmt.Errorf("invalid stl disk format code %q", dfc)
+	}
+
+	fps, err := strconv.Atoi(dfc[3:5])
+	if err != nil || fps <= 0 {
+		return nil, nil, fmt.Errorf("invalid stl disk format code %q", dfc)
+	}
+	frameRate := FrameRate{Num: fps, Den: 1}
+
+	opts := &STLOptions{
+		FrameRate:          frameRate,
+		DisplayStandard:    STLDisplayStandard(gsi[11]),
+		CharacterCodeTable: STLCharacterCodeTable(gsi[12:14]),
+		LanguageCode:       strings.TrimSpace(string(gsi[14:16])),
+		ProgrammeTitle:     strings.TrimSpace(string(gsi[16:48])),
+		EpisodeTitle:       strings.TrimSpace(string(gsi[48:80])),
+		CountryOfOrigin:    strings.TrimSpace(string(gsi[274:277])),
+		Publisher:          strings.TrimSpace(string(gsi[277:309])),
+	}
+
+	if date, err := time.Parse("060102", string(gsi[224:230])); err == nil {
+		opts.CreationDate = date
+	}
+
+	if tcp := string(gsi[256:264]); tcp != "00000000" {
+		if tc, err := ParseTimecode(tcp[0:2]+":"+tcp[2:4]+":"+tcp[4:6]+":"+tcp[6:8], frameRate); err == nil {
+			opts.StartTimecode = tc
+		}
+	}
+
+	charset, ok := stlCharsets[opts.CharacterCodeTable]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported stl character code table %q", opts.CharacterCodeTable)
+	}
+
+	var (
+		cues []Cue
+		text []byte
+	)
+
+	tti := make([]byte, stlTTISize)
+	for {
+		if _, err := io.ReadFull(r, tti); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed reading tti block %w", err)
+		}
+
+		ebn := tti[3]
+		if ebn == stlUserData || tti[15] != 0 {
+			continue
+		}
+
+		field := tti[16:]
+		if i := bytes.IndexByte(field, stlUnused); i >= 0 {
+			field = field[:i]
+		}
+		text = append(text, field...)
+
+		if ebn != stlLastBlock {
+			continue
+		}
+
+		start, err := stlDuration(tti[5:9], opts.StartTimecode, frameRate)
+		if err != nil {
+			return nil, nil, err
+		}
+		end, err := stlDuration(tti[9:13], opts.StartTimecode, frameRate)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cues = append(cues, Cue{
+			Start: start,
+			End:   end,
+			Lines: stlDecodeText(charset, text),
+		})
+		text = nil
+	}
+
+	return cues, opts, nil
+}
+
+func stlDecodeText(charset stlCharset, text []byte) []string {
+	var lines []string
+
+	for _, line := range bytes.Split(text, []byte{stlNewLine}) {
+		var b []byte
+		for _, c := range line {
+			// teletext and formatting control codes are not part of the text.
+			if c < 0x20 || (c >= 0x80 && c <= 0x9f) {
+				continue
+			}
+			b = append(b, c)
+		}
+
+		if s := strings.TrimSpace(charset.decode(b)); s != "" {
+			lines = append(lines, s)
+		}
+	}
+
+	return lines
+}
+
+// timecode returns the timecode of d in the programme.
+func (o STLOptions) timecode(d time.Duration) Timecode {
+	return o.StartTimecode.Convert(o.FrameRate, TimecodeRoundNearest).AddDuration(d)
+}
+
+// stlTimecode converts tc into hours, minutes, seconds and frames.
+func stlTimecode(tc Timecode) []byte {
+	h, m, s, f := tc.Components()
+	return []byte{byte(h % 24), byte(m), byte(s), byte(f)}
+}
+
+// stlDuration converts a TTI timecode into the time since start.
+func stlDuration(b []byte, start Timecode, frameRate FrameRate) (time.Duration, error) {
+	tc, err := TimecodeFromComponents(int(b[0]), int(b[1]), int(b[2]), int(b[3]), frameRate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tti timecode %w", err)
+	}
+	if start.Frames > tc.Frames {
+		return 0, nil
+	}
+	return tc.Add(-start.Frames).Duration(), nil
+}
+
+// stlASCII replaces characters that can not be written to the GSI block.
+func stlASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+type stlCharset interface {
+	encode(r rune) []byte
+	decode(b []byte) string
+}
+
+var stlCharsets = map[STLCharacterCodeTable]stlCharset{
+	STLLatin:    newISO6937(),
+	STLCyrillic: newSingleByteCharset(map[byte]rune{0xad: 0x00ad, 0xf0: '№', 0xfd: '§'}, stlRange{0xa1, 0xac, 0x0401}, stlRange{0xae, 0xef, 0x040e}, stlRange{0xf1, 0xfc, 0x0451}, stlRange{0xfe, 0xff, 0x045e}),
+	STLArabic:   newSingleByteCharset(map[byte]rune{0xac: '،', 0xbb: '؛', 0xbf: '؟'}, stlRange{0xc1, 0xda, 0x0621}, stlRange{0xe0, 0xf2, 0x0640}),
+	STLGreek:    newSingleByteCharset(map[byte]rune{0xb6: 'Ά', 0xbc: 'Ό'}, stlRange{0xb8, 0xba, 0x0388}, stlRange{0xbe, 0xd1, 0x038e}, stlRange{0xd3, 0xfe, 0x03a3}),
+	STLHebrew:   newSingleByteCharset(nil, stlRange{0xe0, 0xfa, 0x05d0}),
+}
+
+// stlRange maps the bytes from first to last onto consecutive runes.
+type stlRange struct {
+	first, last byte
+	r           rune
+}
+
+type singleByteCharset struct {
+	toRune map[byte]rune
+	toByte map[rune]byte
+}
+
+// newSingleByteCharset creates a charset of ASCII plus the given upper half characters.
+func newSingleByteCharset(chars map[byte]rune, ranges ...stlRange) *singleByteCharset {
+	c := &singleByteCharset{toRune: map[byte]rune{}, toByte: map[rune]byte{}}
+
+	for b, r := range chars {
+		c.add(b, r)
+	}
+
+	for _, rng := range ranges {
+		for b := int(rng.first); b <= int(rng.last); b++ {
+			c.add(byte(b), rng.r+rune(b-int(rng.first)))
+		}
+	}
+
+	return c
+}
+
+func (c *singleByteCharset) add(b byte, r rune) {
+	c.toRune[b] = r
+	c.toByte[r] = b
+}
+
+func (c *singleByteCharset) encode(r rune) []byte {
+	if r >= 0x20 && r < 0x7f {
+		return []byte{byte(r)}
+	}
+	if b, ok := c.toByte[r]; ok {
+		return []byte{b}
+	}
+	return []byte{'?'}
+}
+
+func (c *singleByteCharset) decode(b []byte) string {
+	var s strings.Builder
+	for _, c1 := range b {
+		if c1 < 0x80 {
+			s.WriteByte(c1)
+			continue
+		}
+		if r, ok := c.toRune[c1]; ok {
+			s.WriteRune(r)
+		}
+	}
+	return s.String()
+}
+
+// iso6937 is the latin character code table, where accented characters are
+// sent as a non-spacing diacritical mark followed by the base letter.
+type iso6937 struct {
+	spacing  map[byte]rune
+	toByte   map[rune]byte
+	composed map[rune][2]byte
+	compose  map[[2]byte]rune
+}
+
+// iso6937Diacritics lists the composed characters for each diacritical mark,
+// followed by their base letters.
+var iso6937Diacritics = []struct {
+	mark           byte
+	composed, base string
+}{
+	{0xc1, "ÀÈÌÒÙàèìòù", "AEIOUaeiou"},
+	{0xc2, "ÁĆÉÍĹŃÓŔŚÚÝŹáćéíĺńóŕśúýź", "ACEILNORSUYZaceilnorsuyz"},
+	{0xc3, "ÂĈÊĜĤÎĴÔŜÛŴŶâĉêĝĥîĵôŝûŵŷ", "ACEGHIJOSUWYaceghijosuwy"},
+	{0xc4, "ÃĨÑÕŨãĩñõũ", "AINOUainou"},
+	{0xc5, "ĀĒĪŌŪāēīōū", "AEIOUaeiou"},
+	{0xc6, "ĂĞŬăğŭ", "AGUagu"},
+	{0xc7, "ĊĖĠİŻċėġż", "CEGIZcegz"},
+	{0xc8, "ÄËÏÖÜŸäëïöüÿ", "AEIOUYaeiouy"},
+	{0xca, "ÅŮåů", "AUau"},
+	{0xcb, "ÇĢĶĻŅŖŞŢçķļņŗşţ", "CGKLNRSTcklnrst"},
+	{0xcd, "ŐŰőű", "OUou"},
+	{0xce, "ĄĘĮŲąęįų", "AEIUaeiu"},
+	{0xcf, "ČĎĚĽŇŘŠŤŽčďěľňřšťž", "CDELNRSTZcdelnrstz"},
+}
+
+// iso6937Spacing lists the characters of the upper half that are not diacritical marks.
+var iso6937Spacing = map[byte]rune{
+	0xa1: '¡', 0xa2: '¢', 0xa3: '£', 0xa5: '¥', 0xa7: '§', 0xa9: '‘', 0xaa: '“', 0xab: '«',
+	0xb0: '°', 0xb1: '±', 0xb2: '²', 0xb3: '³', 0xb4: '×', 0xb5: 'µ', 0xb6: '¶', 0xb7: '·',
+	0xb8: '÷', 0xb9: '’', 0xba: '”', 0xbb: '»', 0xbc: '¼', 0xbd: '½', 0xbe: '¾', 0xbf: '¿',
+	0xd0: '―', 0xd1: '¹', 0xd2: '®', 0xd3: '©', 0xd4: '™', 0xd5: '♪', 0xd6: '¬', 0xd7: '¦',
+	0xdc: '⅛', 0xdd: '⅜', 0xde: '⅝', 0xdf: '⅞',
+	0xe0: 'Ω', 0xe1: 'Æ', 0xe2: 'Đ', 0xe3: 'ª', 0xe4: 'Ħ', 0xe6: 'Ĳ', 0xe7: 'Ŀ', 0xe8: 'Ł',
+	0xe9: 'Ø', 0xea: 'Œ', 0xeb: 'º', 0xec: 'Þ', 0xed: 'Ŧ', 0xee: 'Ŋ', 0xef: 'ŉ',
+	0xf0: 'ĸ', 0xf1: 'æ', 0xf2: 'đ', 0xf3: 'ð', 0xf4: 'ħ', 0xf5: 'ı', 0xf6: 'ĳ', 0xf7: 'ŀ',
+	0xf8: 'ł', 0xf9: 'ø', 0xfa: 'œ', 0xfb: 'ß', 0xfc: 'þ', 0xfd: 'ŧ', 0xfe: 'ŋ',
+}
+
+func newISO6937() *iso6937 {
+	c := &iso6937{
+		spacing:  iso6937Spacing,
+		toByte:   map[rune]byte{},
+		composed: map[rune][2]byte{},
+		compose:  map[[2]byte]rune{},
+	}
+
+	for b, r := range iso6937Spacing {
+		c.toByte[r] = b
+	}
+
+	for _, d := range iso6937Diacritics {
+		base := []rune(d.base)
+		for i, r := range []rune(d.composed) {
+			pair := [2]byte{d.mark, byte(base[i])}
+			c.composed[r] = pair
+			c.compose[pair] = r
+		}
+	}
+
+	return c
+}
+
+func (c *iso6937) encode(r rune) []byte {
+	if r >= 0x20 && r < 0x7f {
+		return []byte{byte(r)}
+	}
+	if b, ok := c.toByte[r]; ok {
+		return []byte{b}
+	}
+	if pair, ok := c.composed[r]; ok {
+		return pair[:]
+	}
+	return []byte{'?'}
+}
+
+func (c *iso6937) decode(b []byte) string {
+	var s strings.Builder
+
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] < 0x80:
+			s.WriteByte(b[i])
+		case b[i] >= 0xc1 && b[i] <= 0xcf && i+1 < len(b):
+			if r, ok := c.compose[[2]byte{b[i], b[i+1]}]; ok {
+				s.WriteRune(r)
+			} else {
+				s.WriteByte(b[i+1])
+			}
+			i++
+		default:
+			if r, ok := c.spacing[b[i]]; ok {
+				s.WriteRune(r)
+			}
+		}
+	}
+
+	return s.String()
+}
@@ -0,0 +1,176 @@
+package revai
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSTL(t *testing.T) {
+	cues := testTranscript.Cues(&CueOptions{MaxCharsPerLine: stlMaxChars})
+
+	buf := new(bytes.Buffer)
+	opts := &STLOptions{ProgrammeTitle: "Test Programme"}
+	if err := EncodeSTL(buf, cues, opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, stlGSISize+len(cues)*stlTTISize, buf.Len())
+	assert.Equal(t, "850STL25.011", string(buf.Bytes()[:12]))
+
+	decoded, decodedOpts, err := DecodeSTL(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, FrameRate25, decodedOpts.FrameRate)
+	assert.Equal(t, STLLatin, decodedOpts.CharacterCodeTable)
+	assert.Equal(t, STLTeletextLevel1, decodedOpts.DisplayStandard)
+	assert.Equal(t, "Test Programme", decodedOpts.ProgrammeTitle)
+
+	if !assert.Len(t, decoded, len(cues)) {
+		return
+	}
+
+	// timings are rounded to the nearest frame.
+	for i, cue := range decoded {
+		assert.Equal(t, cues[i].Lines, cue.Lines)
+		assert.InDelta(t, cues[i].Start, cue.Start, float64(20*time.Millisecond))
+		assert.InDelta(t, cues[i].End, cue.End, float64(20*time.Millisecond))
+	}
+}
+
+func TestEncodeSTL_StartTimecode(t *testing.T) {
+	start, err := ParseTimecode("10:00:00:00", FrameRate25)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cues := []Cue{{Start: time.Second, End: 2 * time.Second, Lines: []string{"Hello"}}}
+
+	buf := new(bytes.Buffer)
+	if err := EncodeSTL(buf, cues, &STLOptions{StartTimecode: start}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, "1000000010000100", string(buf.Bytes()[256:272]))
+	assert.Equal(t, []byte{10, 0, 1, 0}, buf.Bytes()[stlGSISize+5:stlGSISize+9])
+
+	decoded, opts, err := DecodeSTL(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, start, opts.StartTimecode)
+	assert.Equal(t, cues, decoded)
+}
+
+func TestEncodeSTL_CharacterCodeTables(t *testing.T) {
+	tests := []struct {
+		table STLCharacterCodeTable
+		text  string
+	}{
+		{STLLatin, "Ça va très bien, Łódź ♪ ½"},
+		{STLCyrillic, "Привет, мир Ё"},
+		{STLGreek, "Καλημέρα κόσμε"},
+		{STLHebrew, "שלום עולם"},
+		{STLArabic, "مرحبا، بالعالم"},
+	}
+
+	for _, tt := range tests {
+		cues := []Cue{{Start: time.Second, End: 2 * time.Second, Lines: []string{tt.text}}}
+
+		buf := new(bytes.Buffer)
+		opts := &STLOptions{
+			FrameRate:          FrameRate30,
+			CharacterCodeTable: tt.table,
+			DisplayStandard:    STLOpenSubtitling,
+		}
+		if err := EncodeSTL(buf, cues, opts); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		decoded, _, err := DecodeSTL(buf)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		assert.Equal(t, cues, decoded, "table %s", tt.table)
+	}
+}
+
+func TestEncodeSTL_ExtensionBlocks(t *testing.T) {
+	cues := []Cue{{
+		Start: 1040 * time.Millisecond,
+		End:   5 * time.Second,
+		Lines: []string{
+			"This subtitle is long enough that it",
+			"does not fit inside of a single text",
+			"field and needs an extension block",
+		},
+	}}
+
+	buf := new(bytes.Buffer)
+	if err := EncodeSTL(buf, cues, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, stlGSISize+2*stlTTISize, buf.Len())
+	assert.Equal(t, "00002", string(buf.Bytes()[238:243]))
+	assert.Equal(t, "00001", string(buf.Bytes()[243:248]))
+
+	decoded, _, err := DecodeSTL(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, cues, decoded)
+}
+
+func TestEncodeSTL_Wrap(t *testing.T) {
+	cues := []Cue{{
+		Start: time.Second,
+		End:   2 * time.Second,
+		Lines: []string{"a line of text which is much too long for a teletext row"},
+	}}
+
+	buf := new(bytes.Buffer)
+	if err := EncodeSTL(buf, cues, nil); err != nil {
+		t.Error(err)
+		return
+	}
+
+	decoded, _, err := DecodeSTL(buf)
+	if assert.NoError(t, err) && assert.Len(t, decoded, 1) {
+		assert.Equal(t, []string{"a line of text which is much too long", "for a teletext row"}, decoded[0].Lines)
+	}
+}
+
+func TestEncodeSTL_TooManyCues(t *testing.T) {
+	cues := make([]Cue, stlMaxSubtitles+1)
+	for i := range cues {
+		cues[i] = Cue{Start: time.Duration(i) * time.Second, End: time.Duration(i+1) * time.Second, Lines: []string{"hi"}}
+	}
+
+	err := EncodeSTL(new(bytes.Buffer), cues, nil)
+	assert.Error(t, err)
+}
+
+func TestDecodeSTL_Invalid(t *testing.T) {
+	_, _, err := DecodeSTL(bytes.NewReader(make([]byte, 100)))
+	assert.Error(t, err)
+
+	_, _, err = DecodeSTL(bytes.NewReader(bytes.Repeat([]byte{' '}, stlGSISize)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package revai
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stmIgnore is the STM text of segments excluded from scoring.
+const stmIgnore = "ignore_time_segment_in_scoring"
+
+// STMOptions specifies the fields written by EncodeSTM.
+// DecodeSTM returns the speaker names read from a file, and the label when
+// all segments have the same one.
+type STMOptions struct {
+	// File is the waveform file name. It defaults to "rev".
+	File string
+
+	// Channel is the waveform channel. It defaults to "1".
+	Channel string
+
+	// SpeakerNames maps Monologue.Speaker to its STM speaker ID, which is
+	// written with spaces replaced by underscores. Speakers without a name
+	// are called "Speaker_N".
+	SpeakerNames map[int]string
+
+	// Label is an optional segment label such as <o,f0,male>.
+	Label string
+}
+
+// EncodeSTM writes the transcript to w as a NIST segment time marked file with
+// a segment per monologue. Punctuation is left out of the segment text.
+func EncodeSTM(w io.Writer, t *Transcript, opts *STMOptions) error {
+	if opts == nil {
+		opts = &STMOptions{}
+	}
+	file, channel := opts.File, opts.Channel
+	if file == "" {
+		file = "rev"
+	}
+	if channel == "" {
+		channel = "1"
+	}
+
+	type segment struct {
+		speaker    int
+		start, end float64
+		words      []string
+	}
+
+	var segments []segment
+	for _, monologue := range t.Monologues {
+		seg := segment{speaker: monologue.Speaker}
+		for _, element := range monologue.Elements {
+			if element.Type != ElementText {
+				continue
+			}
+			if len(seg.words) == 0 {
+				seg.start = element.Ts
+			}
+			seg.end = element.EndTs
+			seg.words = append(seg.words, strings.Fields(element.Value)...)
+		}
+		if len(seg.words) > 0 {
+			segments = append(segments, seg)
+		}
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].start < segments[j].start })
+
+	label := ""
+	if opts.Label != "" {
+		label = evaluationField(opts.Label) + " "
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, seg := range segments {
+		speaker := opts.SpeakerNames[seg.speaker]
+		if speaker == "" {
+			speaker = "Speaker " + strconv.Itoa(seg.speaker)
+		}
+
+		fmt.Fprintf(bw, "%s %s %s %.3f %.3f %s%s\n",
+			evaluationField(file), evaluationField(channel), evaluationField(speaker),
+			seg.start, seg.end, label, strings.Join(seg.words, " "))
+	}
+
+	return bw.Flush()
+}
+
+// DecodeSTM reads a NIST segment time marked file and returns a transcript
+// for each waveform file in it, with a monologue per segment. Segment words
+// are spread evenly over the segment and segments excluded from scoring are
+// skipped. Speaker IDs named "Speaker_N" are read as speaker N, other speakers
+// are numbered across all files and their names returned in the options with
+// underscores replaced by spaces.
+func DecodeSTM(r io.Reader) (map[string]*Transcript, *STMOptions, error) {
+	type segment struct {
+		file string
+		annotation
+	}
+
+	var (
+		speakers []speakerAnnotations
+		segments [][]segment
+		index    = map[string]int{}
+		labels   = map[string]bool{}
+	)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";;") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			return nil, nil, fmt.Errorf("invalid stm line %d", n)
+		}
+
+		begin, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid stm begin time on line %d", n)
+		}
+		end, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid stm end time on line %d", n)
+		}
+
+		words := fields[5:]
+		if len(words) > 0 && strings.HasPrefix(words[0], "<") && strings.HasSuffix(words[0], ">") {
+			labels[words[0]] = true
+			words = words[1:]
+		}
+		text := strings.Join(words, " ")
+		if text == "" || strings.EqualFold(text, stmIgnore) || strings.EqualFold(fields[2], "inter_segment_gap") {
+			continue
+		}
+
+		name := strings.ReplaceAll(fields[2], "_", " ")
+		i, ok := index[name]
+		if !ok {
+			i = len(speakers)
+			index[name] = i
+			speakers = append(speakers, speakerAnnotations{name: name})
+			segments = append(segments, nil)
+		}
+		segments[i] = append(segments[i], segment{fields[0], annotation{start: begin, end: end, text: text}})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed reading stm %w", err)
+	}
+
+	opts := &STMOptions{SpeakerNames: numberSpeakers(speakers)}
+	if len(labels) == 1 {
+		for label := range labels {
+			opts.Label = label
+		}
+	}
+
+	// speakers are numbered across files, then each file is read on its own.
+	files := map[string][]speakerAnnotations{}
+	for i, sa := range speakers {
+		byFile := map[string]*speakerAnnotations{}
+		var order []string
+		for _, seg := range segments[i] {
+			fsa, ok := byFile[seg.file]
+			if !ok {
+				fsa = &speakerAnnotations{speaker: sa.speaker, name: sa.name}
+				byFile[seg.file] = fsa
+				order = append(order, seg.file)
+			}
+			fsa.turns = append(fsa.turns, seg.annotation)
+		}
+		for _, file := range order {
+			files[file] = append(files[file], *byFile[file])
+		}
+	}
+
+	transcripts := map[string]*Transcript{}
+	for file, speakers := range files {
+		transcripts[file] = annotationsTranscript(speakers)
+	}
+
+	return transcripts, opts, nil
+}
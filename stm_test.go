@@ -0,0 +1,66 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSTM(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := &STMOptions{File: "interview", SpeakerNames: map[int]string{1: "Bob Smith"}, Label: "<o,f0,male>"}
+	if err := EncodeSTM(buf, testTranscript, opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, "interview 1 Speaker_0 0.500 2.100 <o,f0,male> Hello my name is Jane\n"+
+		"interview 1 Bob_Smith 2.500 3.400 <o,f0,male> Nice to meet you\n", buf.String())
+
+	transcripts, decodedOpts, err := DecodeSTM(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, map[int]string{1: "Bob Smith"}, decodedOpts.SpeakerNames)
+	assert.Equal(t, "<o,f0,male>", decodedOpts.Label)
+
+	decoded := transcripts["interview"]
+	if !assert.NotNil(t, decoded) || !assert.Len(t, decoded.Monologues, 2) {
+		return
+	}
+	assert.Equal(t, 1, decoded.Monologues[1].Speaker)
+	// segment words are spread evenly over the segment.
+	hello := decoded.Monologues[0].Elements[0]
+	assert.Equal(t, "Hello", hello.Value)
+	assert.InDelta(t, 0.5, hello.Ts, 1e-9)
+	assert.InDelta(t, 0.82, hello.EndTs, 1e-9)
+}
+
+func TestDecodeSTM(t *testing.T) {
+	const stm = `;; CATEGORY "0" "" ""
+a 1 inter_segment_gap 0.0 1.0
+a 1 alice 1.0 2.0 good morning
+b 1 alice 0.0 1.0 hello
+b 1 Speaker_0 1.0 2.0 ignore_time_segment_in_scoring
+b 1 Speaker_0 2.0 3.0 bye
+`
+
+	transcripts, opts, err := DecodeSTM(strings.NewReader(stm))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, map[int]string{1: "alice"}, opts.SpeakerNames)
+	assert.Len(t, transcripts, 2)
+	assert.Len(t, transcripts["a"].Monologues, 1)
+	assert.Equal(t, 1, transcripts["a"].Monologues[0].Speaker)
+	if assert.Len(t, transcripts["b"].Monologues, 2) {
+		assert.Equal(t, 1, transcripts["b"].Monologues[0].Speaker)
+		assert.Equal(t, 0, transcripts["b"].Monologues[1].Speaker)
+	}
+}
@@ -0,0 +1,339 @@
+package revai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	textGridWordsSuffix   = " words"
+	textGridPhrasesSuffix = " phrases"
+)
+
+// TextGridOptions specifies the tiers written by EncodeTextGrid.
+// DecodeTextGrid returns the options read from a file.
+type TextGridOptions struct {
+	// SpeakerNames maps Monologue.Speaker to the name of its tiers.
+	// Speakers without a name are called "Speaker N".
+	SpeakerNames map[int]string
+
+	// Words adds a "<speaker> words" tier with an interval per text element.
+	Words bool
+
+	// Phrases adds a "<speaker> phrases" tier with an interval per run of
+	// words between punctuation.
+	Phrases bool
+}
+
+// EncodeTextGrid writes the transcript to w as a Praat TextGrid in the long
+// text format with an interval tier per speaker holding its monologues.
+func EncodeTextGrid(w io.Writer, t *Transcript, opts *TextGridOptions) error {
+	if opts == nil {
+		opts = &TextGridOptions{}
+	}
+
+	type tier struct {
+		name      string
+		intervals []annotation
+	}
+
+	var (
+		tiers []tier
+		xmax  float64
+	)
+
+	for _, sa := range t.speakerAnnotations(opts.SpeakerNames) {
+		tiers = append(tiers, tier{sa.name, sa.turns})
+		if opts.Phrases {
+			tiers = append(tiers, tier{sa.name + textGridPhrasesSuffix, sa.phrases})
+		}
+		if opts.Words {
+			tiers = append(tiers, tier{sa.name + textGridWordsSuffix, sa.words})
+		}
+		for _, a := range sa.words {
+			if a.end > xmax {
+				xmax = a.end
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n")
+	fmt.Fprintf(bw, "xmin = 0 \nxmax = %s \n", textGridNumber(xmax))
+
+	if len(tiers) == 0 {
+		fmt.Fprintf(bw, "tiers? <absent> \n")
+		return bw.Flush()
+	}
+
+	fmt.Fprintf(bw, "tiers? <exists> \nsize = %d \nitem []: \n", len(tiers))
+
+	for i, tier := range tiers {
+		intervals := textGridIntervals(tier.intervals, xmax)
+
+		fmt.Fprintf(bw, "    item [%d]:\n", i+1)
+		fmt.Fprintf(bw, "        class = \"IntervalTier\" \n")
+		fmt.Fprintf(bw, "        name = %s \n", textGridString(tier.name))
+		fmt.Fprintf(bw, "        xmin = 0 \n        xmax = %s \n", textGridNumber(xmax))
+		fmt.Fprintf(bw, "        intervals: size = %d \n", len(intervals))
+		for j, a := range intervals {
+			fmt.Fprintf(bw, "        intervals [%d]:\n", j+1)
+			fmt.Fprintf(bw, "            xmin = %s \n", textGridNumber(a.start))
+			fmt.Fprintf(bw, "            xmax = %s \n", textGridNumber(a.end))
+			fmt.Fprintf(bw, "            text = %s \n", textGridString(a.text))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// textGridIntervals fills the gaps between annotations with empty intervals so
+// the tier covers 0 to xmax. Annotations overlapping the one before them are
+// started when it ends.
+func textGridIntervals(annotations []annotation, xmax float64) []annotation {
+	var (
+		intervals []annotation
+		at        float64
+	)
+
+	for _, a := range annotations {
+		if a.start < at {
+			a.start = at
+		}
+		if a.end <= a.start {
+			continue
+		}
+		if a.start > at {
+			intervals = append(intervals, annotation{start: at, end: a.start})
+		}
+		intervals = append(intervals, a)
+		at = a.end
+	}
+
+	if at < xmax || len(intervals) == 0 {
+		intervals = append(intervals, annotation{start: at, end: xmax})
+	}
+
+	return intervals
+}
+
+func textGridNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func textGridString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// DecodeTextGrid reads a Praat TextGrid in the long or short text format.
+// Interval tiers become speakers, and a "<speaker> words" tier gives the
+// timing of the words of that speaker. Words of intervals without a words tier
+// are spread evenly over the interval. "<speaker> phrases" tiers and point
+// tiers are ignored. Tiers named "Speaker N" are read as speaker N, other names
+// are returned in the options.
+func DecodeTextGrid(r io.Reader) (*Transcript, *TextGridOptions, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading textgrid %w", err)
+	}
+
+	text := string(data)
+	if strings.HasPrefix(text, "\xfe\xff") || strings.HasPrefix(text, "\xff\xfe") {
+		return nil, nil, errors.New("utf-16 textgrids are not supported")
+	}
+
+	tok := &textGridTokenizer{s: strings.TrimPrefix(text, "\ufeff")}
+
+	if s, err := tok.string(); err != nil || s != "ooTextFile" {
+		return nil, nil, errors.New("missing textgrid file type")
+	}
+	if s, err := tok.string(); err != nil || s != "TextGrid" {
+		return nil, nil, errors.New("missing textgrid object class")
+	}
+
+	if _, err := tok.number(); err != nil {
+		return nil, nil, err
+	}
+	if _, err := tok.number(); err != nil {
+		return nil, nil, err
+	}
+
+	opts := &TextGridOptions{}
+
+	if !tok.exists() {
+		return &Transcript{}, opts, nil
+	}
+
+	size, err := tok.number()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		speakers []speakerAnnotations
+		words    = map[string][]annotation{}
+	)
+
+	for i := 0; i < int(size); i++ {
+		class, err := tok.string()
+		if err != nil {
+			return nil, nil, err
+		}
+		name, err := tok.string()
+		if err != nil {
+			return nil, nil, err
+		}
+		for j := 0; j < 2; j++ {
+			if _, err := tok.number(); err != nil {
+				return nil, nil, err
+			}
+		}
+		n, err := tok.number()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var annotations []annotation
+		for j := 0; j < int(n); j++ {
+			var a annotation
+			if a.start, err = tok.number(); err != nil {
+				return nil, nil, err
+			}
+			a.end = a.start
+			if class == "IntervalTier" {
+				if a.end, err = tok.number(); err != nil {
+					return nil, nil, err
+				}
+			}
+			if a.text, err = tok.string(); err != nil {
+				return nil, nil, err
+			}
+			if strings.TrimSpace(a.text) != "" {
+				annotations = append(annotations, a)
+			}
+		}
+
+		switch {
+		case class != "IntervalTier":
+		case strings.HasSuffix(name, textGridWordsSuffix):
+			opts.Words = true
+			words[strings.TrimSuffix(name, textGridWordsSuffix)] = annotations
+		case strings.HasSuffix(name, textGridPhrasesSuffix):
+			opts.Phrases = true
+		default:
+			speakers = append(speakers, speakerAnnotations{name: name, turns: annotations})
+		}
+	}
+
+	for i := range speakers {
+		speakers[i].words = words[speakers[i].name]
+	}
+	opts.SpeakerNames = numberSpeakers(speakers)
+
+	return annotationsTranscript(speakers), opts, nil
+}
+
+// textGridTokenizer reads the numbers, strings and flags of a TextGrid text
+// file, skipping labels and comments so the long and short formats are read
+// the same way.
+type textGridTokenizer struct {
+	s string
+}
+
+// next returns the next token, quoted strings are returned with their quotes.
+func (t *textGridTokenizer) next() (string, error) {
+	for {
+		t.s = strings.TrimLeftFunc(t.s, unicode.IsSpace)
+		if t.s == "" {
+			return "", io.ErrUnexpectedEOF
+		}
+
+		switch c := t.s[0]; {
+		case c == '"':
+			end := 1
+			for {
+				i := strings.IndexByte(t.s[end:], '"')
+				if i < 0 {
+					return "", errors.New("unterminated textgrid string")
+				}
+				end += i + 1
+				if end < len(t.s) && t.s[end] == '"' {
+					end++
+					continue
+				}
+				break
+			}
+			tok := t.s[:end]
+			t.s = t.s[end:]
+			return tok, nil
+		case c == '!':
+			if i := strings.IndexByte(t.s, '\n'); i >= 0 {
+				t.s = t.s[i:]
+			} else {
+				t.s = ""
+			}
+			continue
+		case c == '[':
+			// array indexes such as item [1] are labels.
+			if i := strings.IndexByte(t.s, ']'); i >= 0 {
+				t.s = t.s[i+1:]
+				continue
+			}
+		}
+
+		i := strings.IndexFunc(t.s, unicode.IsSpace)
+		if i < 0 {
+			i = len(t.s)
+		}
+		tok := t.s[:i]
+		t.s = t.s[i:]
+
+		if _, err := strconv.ParseFloat(tok, 64); err == nil || tok == "<exists>" || tok == "<absent>" {
+			return tok, nil
+		}
+	}
+}
+
+func (t *textGridTokenizer) string() (string, error) {
+	tok, err := t.next()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(tok, `"`) {
+		return "", fmt.Errorf("expected textgrid string, got %q", tok)
+	}
+	return strings.ReplaceAll(tok[1:len(tok)-1], `""`, `"`), nil
+}
+
+func (t *textGridTokenizer) number() (float64, error) {
+	tok, err := t.next()
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected textgrid number, got %q", tok)
+	}
+	return f, nil
+}
+
+// exists reads the tiers flag, which is absent in some short text files.
+func (t *textGridTokenizer) exists() bool {
+	s := t.s
+	tok, err := t.next()
+	if err != nil {
+		return false
+	}
+	if tok != "<exists>" && tok != "<absent>" {
+		t.s = s
+		return true
+	}
+	return tok == "<exists>"
+}
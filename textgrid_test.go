@@ -0,0 +1,100 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withoutConfidence returns a copy of t with element confidences cleared.
+func withoutConfidence(t *Transcript) *Transcript {
+	c := &Transcript{}
+	for _, m := range t.Monologues {
+		elements := append([]Element(nil), m.Elements...)
+		for i := range elements {
+			elements[i].Confidence = 0
+		}
+		c.Monologues = append(c.Monologues, Monologue{Speaker: m.Speaker, Elements: elements})
+	}
+	return c
+}
+
+func TestEncodeTextGrid(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := &TextGridOptions{SpeakerNames: map[int]string{1: "Bob \"B\""}, Words: true, Phrases: true}
+	if err := EncodeTextGrid(buf, testTranscript, opts); err != nil {
+		t.Error(err)
+		return
+	}
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\nxmin = 0 \nxmax = 3.4 \ntiers? <exists> \nsize = 6 \n"))
+	assert.Contains(t, out, "name = \"Speaker 0 phrases\" \n")
+	assert.Contains(t, out, "name = \"Bob \"\"B\"\" words\" \n")
+	assert.Contains(t, out, "            xmin = 0.5 \n            xmax = 2.1 \n            text = \"Hello, my name is Jane.\" \n")
+	assert.Contains(t, out, "            xmin = 0.5 \n            xmax = 0.9 \n            text = \"Hello,\" \n")
+
+	decoded, decodedOpts, err := DecodeTextGrid(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, withoutConfidence(testTranscript), withoutConfidence(decoded))
+	assert.Equal(t, map[int]string{1: "Bob \"B\""}, decodedOpts.SpeakerNames)
+	assert.True(t, decodedOpts.Words)
+	assert.True(t, decodedOpts.Phrases)
+}
+
+func TestDecodeTextGrid_Short(t *testing.T) {
+	const short = `File type = "ooTextFile"
+Object class = "TextGrid"
+
+0
+2
+<exists>
+2
+"IntervalTier"
+"Ann"
+0
+2
+2
+0
+1.5
+"good morning."
+1.5
+2
+""
+"TextTier"
+"events"
+0
+2
+1
+1
+"cough"
+`
+
+	decoded, opts, err := DecodeTextGrid(strings.NewReader(short))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, map[int]string{0: "Ann"}, opts.SpeakerNames)
+	assert.Equal(t, &Transcript{Monologues: []Monologue{{
+		Speaker: 0,
+		Elements: []Element{
+			{Type: "text", Value: "good", Ts: 0, EndTs: 0.75, Confidence: 1},
+			{Type: "punct", Value: " "},
+			{Type: "text", Value: "morning", Ts: 0.75, EndTs: 1.5, Confidence: 1},
+			{Type: "punct", Value: "."},
+		},
+	}}}, decoded)
+}
+
+func TestDecodeTextGrid_Invalid(t *testing.T) {
+	_, _, err := DecodeTextGrid(strings.NewReader("WEBVTT"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,331 @@
+package revai
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FrameRate is a video frame rate of Num/Den frames per second.
+// Drop-frame rates skip frame numbers so timecodes stay in step with
+// the wall clock.
+type FrameRate struct {
+	Num       int
+	Den       int
+	DropFrame bool
+}
+
+var (
+	// FrameRate23976 is 23.976fps film transferred to NTSC video.
+	FrameRate23976 = FrameRate{Num: 24000, Den: 1001}
+	// FrameRate24 is 24fps film.
+	FrameRate24 = FrameRate{Num: 24, Den: 1}
+	// FrameRate25 is 25fps PAL video.
+	FrameRate25 = FrameRate{Num: 25, Den: 1}
+	// FrameRate2997 is 29.97fps NTSC video with non-drop-frame timecodes,
+	// which run behind the wall clock by 3.6 seconds an hour.
+	FrameRate2997 = FrameRate{Num: 30000, Den: 1001}
+	// FrameRate2997DF is 29.97fps NTSC video with drop-frame timecodes,
+	// which skip frame numbers 00 and 01 at the start of every minute
+	// except every tenth so they stay in step with the wall clock.
+	FrameRate2997DF = FrameRate{Num: 30000, Den: 1001, DropFrame: true}
+	// FrameRate30 is 30fps video.
+	FrameRate30 = FrameRate{Num: 30, Den: 1}
+	// FrameRate50 is 50fps PAL video.
+	FrameRate50 = FrameRate{Num: 50, Den: 1}
+	// FrameRate5994 is 59.94fps NTSC video with non-drop-frame timecodes.
+	FrameRate5994 = FrameRate{Num: 60000, Den: 1001}
+	// FrameRate5994DF is 59.94fps NTSC video with drop-frame timecodes,
+	// which skip frame numbers 00 to 03 at the start of every minute except
+	// every tenth.
+	FrameRate5994DF = FrameRate{Num: 60000, Den: 1001, DropFrame: true}
+)
+
+// FPS returns the number of frames per second.
+func (r FrameRate) FPS() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// IsZero reports whether r is the zero frame rate.
+func (r FrameRate) IsZero() bool {
+	return r.Num <= 0 || r.Den <= 0
+}
+
+func (r FrameRate) String() string {
+	s := strconv.FormatFloat(math.Round(r.FPS()*1000)/1000, 'f', -1, 64)
+	if r.DropFrame {
+		s += "DF"
+	}
+	return s
+}
+
+// timebase is the number of frames counted per timecode second.
+func (r FrameRate) timebase() int {
+	return int(math.Round(r.FPS()))
+}
+
+// dropped is the number of frame numbers skipped at the start of each minute
+// that is not a multiple of ten.
+func (r FrameRate) dropped() int {
+	if !r.DropFrame {
+		return 0
+	}
+	return r.timebase() / 15
+}
+
+// TimecodeRounding is how times between two frames are snapped to a frame.
+type TimecodeRounding int
+
+const (
+	// TimecodeRoundNearest snaps to the nearest frame, or the later frame
+	// when halfway between two.
+	TimecodeRoundNearest TimecodeRounding = iota
+	// TimecodeRoundDown snaps to the frame the time falls in.
+	TimecodeRoundDown
+	// TimecodeRoundUp snaps to the next frame unless the time is on a frame.
+	TimecodeRoundUp
+)
+
+// Timecode is a SMPTE timecode, counted in frames from 00:00:00:00.
+type Timecode struct {
+	Frames int
+	Rate   FrameRate
+}
+
+// NewTimecode returns the timecode of the frame at d.
+func NewTimecode(d time.Duration, rate FrameRate, rounding TimecodeRounding) Timecode {
+	if rate.IsZero() {
+		return Timecode{Rate: rate}
+	}
+
+	num := d.Nanoseconds() * int64(rate.Num)
+	den := int64(rate.Den) * int64(time.Second)
+
+	frames := num / den
+	rem := num % den
+	if rem < 0 {
+		rem += den
+		frames--
+	}
+
+	// times within a nanosecond of a frame are on it, so durations
+	// returned by Timecode.Duration map back to the same frame.
+	switch {
+	case rem < int64(rate.Num):
+		rem = 0
+	case den-rem <= int64(rate.Num):
+		frames++
+		rem = 0
+	}
+
+	switch rounding {
+	case TimecodeRoundDown:
+	case TimecodeRoundUp:
+		if rem > 0 {
+			frames++
+		}
+	default:
+		if 2*rem >= den {
+			frames++
+		}
+	}
+
+	return Timecode{Frames: int(frames), Rate: rate}
+}
+
+// NewTimecodeSeconds returns the timecode of the frame at s seconds,
+// for example the Ts of an Element.
+func NewTimecodeSeconds(s float64, rate FrameRate, rounding TimecodeRounding) Timecode {
+	return NewTimecode(secondsToDuration(s), rate, rounding)
+}
+
+// ParseTimecode parses a timecode in the form HH:MM:SS:FF. The frames may be
+// separated by a semicolon or a period as is usual for drop-frame timecodes.
+func ParseTimecode(s string, rate FrameRate) (Timecode, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ';' || r == '.' })
+	if len(parts) != 4 {
+		return Timecode{}, fmt.Errorf("invalid timecode %q", s)
+	}
+
+	var n [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return Timecode{}, fmt.Errorf("invalid timecode %q", s)
+		}
+		n[i] = v
+	}
+
+	tc, err := TimecodeFromComponents(n[0], n[1], n[2], n[3], rate)
+	if err != nil {
+		return Timecode{}, fmt.Errorf("invalid timecode %q", s)
+	}
+
+	return tc, nil
+}
+
+// TimecodeFromComponents returns the timecode with the given hours, minutes,
+// seconds and frames.
+func TimecodeFromComponents(h, m, s, f int, rate FrameRate) (Timecode, error) {
+	if rate.IsZero() {
+		return Timecode{}, fmt.Errorf("invalid frame rate %s", rate)
+	}
+
+	tb := rate.timebase()
+	drop := rate.dropped()
+
+	if h < 0 || m < 0 || m > 59 || s < 0 || s > 59 || f < 0 || f >= tb {
+		return Timecode{}, fmt.Errorf("invalid timecode %02d:%02d:%02d:%02d", h, m, s, f)
+	}
+	if drop > 0 && s == 0 && m%10 != 0 && f < drop {
+		return Timecode{}, fmt.Errorf("frame %02d:%02d:%02d;%02d is dropped", h, m, s, f)
+	}
+
+	minutes := h*60 + m
+	frames := (minutes*60+s)*tb + f - drop*(minutes-minutes/10)
+
+	return Timecode{Frames: frames, Rate: rate}, nil
+}
+
+// Components returns the hours, minutes, seconds and frames of the timecode.
+// Negative timecodes return the components of their absolute value.
+func (tc Timecode) Components() (h, m, s, f int) {
+	frames := tc.Frames
+	if frames < 0 {
+		frames = -frames
+	}
+
+	tb := tc.Rate.timebase()
+	if tb <= 0 {
+		return 0, 0, 0, 0
+	}
+
+	if drop := tc.Rate.dropped(); drop > 0 {
+		perMinute := tb*60 - drop
+		per10 := tb*600 - 9*drop
+
+		d := frames / per10
+		r := frames % per10
+		frames += 9 * drop * d
+		if r > drop {
+			frames += drop * ((r - drop) / perMinute)
+		}
+	}
+
+	return frames / (tb * 3600), frames / (tb * 60) % 60, frames / tb % 60, frames % tb
+}
+
+// String formats the timecode as HH:MM:SS:FF, or HH:MM:SS;FF for drop-frame rates.
+func (tc Timecode) String() string {
+	h, m, s, f := tc.Components()
+
+	sep := ":"
+	if tc.Rate.DropFrame {
+		sep = ";"
+	}
+
+	sign := ""
+	if tc.Frames < 0 {
+		sign = "-"
+	}
+
+	return fmt.Sprintf("%s%02d:%02d:%02d%s%02d", sign, h, m, s, sep, f)
+}
+
+// Duration returns the time at which the frame starts.
+func (tc Timecode) Duration() time.Duration {
+	if tc.Rate.IsZero() {
+		return 0
+	}
+	return time.Duration(int64(tc.Frames) * int64(tc.Rate.Den) * int64(time.Second) / int64(tc.Rate.Num))
+}
+
+// Seconds returns the time at which the frame starts in seconds.
+func (tc Timecode) Seconds() float64 {
+	return tc.Duration().Seconds()
+}
+
+// Add returns the timecode the given number of frames later.
+func (tc Timecode) Add(frames int) Timecode {
+	tc.Frames += frames
+	return tc
+}
+
+// AddDuration returns the timecode d later, rounded to the nearest frame.
+func (tc Timecode) AddDuration(d time.Duration) Timecode {
+	return tc.Add(NewTimecode(d, tc.Rate, TimecodeRoundNearest).Frames)
+}
+
+// Sub returns the number of frames from u to tc. u is converted to the frame
+// rate of tc when they differ.
+func (tc Timecode) Sub(u Timecode) int {
+	if u.Rate != tc.Rate {
+		u = u.Convert(tc.Rate, TimecodeRoundNearest)
+	}
+	return tc.Frames - u.Frames
+}
+
+// Convert returns the timecode of the frame at the same time in another frame rate.
+func (tc Timecode) Convert(rate FrameRate, rounding TimecodeRounding) Timecode {
+	return NewTimecode(tc.Duration(), rate, rounding)
+}
+
+// SnapToFrame returns the start of the frame d is snapped to.
+func SnapToFrame(d time.Duration, rate FrameRate, rounding TimecodeRounding) time.Duration {
+	return NewTimecode(d, rate, rounding).Duration()
+}
+
+// SnapCues returns a copy of cues with their times snapped to the nearest
+// frame of rate and offset by start, for captions placed on a timeline
+// starting at a timecode such as 01:00:00:00. Cues last at least one frame.
+// A zero rate leaves the times unsnapped.
+func SnapCues(cues []Cue, rate FrameRate, start Timecode) []Cue {
+	timing := cueTiming{rate: rate, start: start}
+
+	snapped := make([]Cue, len(cues))
+	for i, cue := range cues {
+		snapped[i] = timing.cue(cue)
+	}
+	return snapped
+}
+
+// cueTiming snaps caption times to frames and offsets them by a start
+// timecode.
+type cueTiming struct {
+	rate  FrameRate
+	start Timecode
+}
+
+func (c cueTiming) time(d time.Duration) time.Duration {
+	if !c.rate.IsZero() {
+		d = SnapToFrame(d, c.rate, TimecodeRoundNearest)
+	}
+	return c.start.Duration() + d
+}
+
+func (c cueTiming) cue(cue Cue) Cue {
+	cue.Start, cue.End = c.time(cue.Start), c.time(cue.End)
+	if !c.rate.IsZero() && cue.End <= cue.Start {
+		cue.End = cue.Start + Timecode{Frames: 1, Rate: c.rate}.Duration()
+	}
+	return cue
+}
+
+// segments returns a copy of segments with the times of their cues and
+// words snapped.
+func (c cueTiming) segments(segments []captionSegment) []captionSegment {
+	snapped := make([]captionSegment, len(segments))
+	for i, seg := range segments {
+		seg.cue = c.cue(seg.cue)
+		words := make([]captionWord, len(seg.words))
+		for j, w := range seg.words {
+			w.start, w.end = c.time(w.start), c.time(w.end)
+			words[j] = w
+		}
+		seg.words = words
+		snapped[i] = seg
+	}
+	return snapped
+}
@@ -0,0 +1,119 @@
+package revai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimecode(t *testing.T) {
+	tests := []struct {
+		tc     string
+		rate   FrameRate
+		frames int
+	}{
+		{"00:00:01:00", FrameRate24, 24},
+		{"00:00:01:00", FrameRate23976, 24},
+		{"01:00:00:00", FrameRate25, 90000},
+		{"00:00:59;29", FrameRate2997DF, 1799},
+		{"00:01:00;02", FrameRate2997DF, 1800},
+		{"00:10:00;00", FrameRate2997DF, 17982},
+		{"01:00:00;00", FrameRate2997DF, 107892},
+		{"01:00:00:00", FrameRate2997, 108000},
+		{"00:01:00;04", FrameRate5994DF, 3600},
+		{"01:00:00;00", FrameRate5994DF, 215784},
+		{"00:00:10:49", FrameRate50, 549},
+	}
+
+	for _, tt := range tests {
+		tc, err := ParseTimecode(tt.tc, tt.rate)
+		if !assert.NoError(t, err, tt.tc) {
+			continue
+		}
+		assert.Equal(t, tt.frames, tc.Frames, "%s at %s", tt.tc, tt.rate)
+		assert.Equal(t, tt.tc, tc.String())
+	}
+}
+
+func TestParseTimecode_Invalid(t *testing.T) {
+	for _, tc := range []string{"", "00:00:00", "00:60:00:00", "00:00:00:25", "aa:00:00:00"} {
+		_, err := ParseTimecode(tc, FrameRate25)
+		assert.Error(t, err, tc)
+	}
+
+	_, err := ParseTimecode("00:01:00;01", FrameRate2997DF)
+	assert.Error(t, err)
+}
+
+func TestTimecode_DropFrameRoundTrip(t *testing.T) {
+	for _, rate := range []FrameRate{FrameRate2997DF, FrameRate5994DF} {
+		for frames := 0; frames < 40000; frames += 7 {
+			tc := Timecode{Frames: frames, Rate: rate}
+			parsed, err := ParseTimecode(tc.String(), rate)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, frames, parsed.Frames)
+		}
+	}
+}
+
+func TestNewTimecode(t *testing.T) {
+	d := 1010 * time.Millisecond
+
+	assert.Equal(t, 25, NewTimecode(d, FrameRate25, TimecodeRoundNearest).Frames)
+	assert.Equal(t, 25, NewTimecode(d, FrameRate25, TimecodeRoundDown).Frames)
+	assert.Equal(t, 26, NewTimecode(d, FrameRate25, TimecodeRoundUp).Frames)
+	assert.Equal(t, 26, NewTimecode(1030*time.Millisecond, FrameRate25, TimecodeRoundNearest).Frames)
+
+	assert.Equal(t, "00:00:02:12", NewTimecodeSeconds(2.5, FrameRate24, TimecodeRoundNearest).String())
+
+	for _, rate := range []FrameRate{FrameRate23976, FrameRate2997DF, FrameRate5994} {
+		for frames := 0; frames < 1000; frames++ {
+			d := Timecode{Frames: frames, Rate: rate}.Duration()
+			assert.Equal(t, frames, NewTimecode(d, rate, TimecodeRoundDown).Frames)
+			assert.Equal(t, frames, NewTimecode(d, rate, TimecodeRoundUp).Frames)
+		}
+	}
+}
+
+func TestTimecode_Arithmetic(t *testing.T) {
+	start, err := ParseTimecode("01:00:00:00", FrameRate25)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, "01:00:01:05", start.Add(30).String())
+	assert.Equal(t, "01:00:02:13", start.AddDuration(2520*time.Millisecond).String())
+	assert.Equal(t, 30, start.Add(30).Sub(start))
+	assert.Equal(t, "-00:00:01:00", Timecode{Frames: -25, Rate: FrameRate25}.String())
+
+	converted := start.Convert(FrameRate50, TimecodeRoundNearest)
+	assert.Equal(t, "01:00:00:00", converted.String())
+	assert.Equal(t, 0, converted.Sub(start))
+}
+
+func TestSnapToFrame(t *testing.T) {
+	assert.Equal(t, 1040*time.Millisecond, SnapToFrame(1030*time.Millisecond, FrameRate25, TimecodeRoundNearest))
+	assert.Equal(t, time.Second, SnapToFrame(1030*time.Millisecond, FrameRate25, TimecodeRoundDown))
+}
+
+func TestSnapCues(t *testing.T) {
+	start, _ := ParseTimecode("01:00:00:00", FrameRate25)
+	cues := []Cue{
+		{Start: 1030 * time.Millisecond, End: 2010 * time.Millisecond, Lines: []string{"hello"}},
+		// cues shorter than a frame last a frame.
+		{Start: 3000 * time.Millisecond, End: 3010 * time.Millisecond, Lines: []string{"hi"}},
+	}
+
+	assert.Equal(t, []Cue{
+		{Start: time.Hour + 1040*time.Millisecond, End: time.Hour + 2000*time.Millisecond, Lines: []string{"hello"}},
+		{Start: time.Hour + 3000*time.Millisecond, End: time.Hour + 3040*time.Millisecond, Lines: []string{"hi"}},
+	}, SnapCues(cues, FrameRate25, start))
+	assert.Equal(t, 1030*time.Millisecond, cues[0].Start)
+
+	// without a frame rate cues are only offset.
+	assert.Equal(t, time.Hour+1030*time.Millisecond, SnapCues(cues, FrameRate{}, start)[0].Start)
+}
@@ -0,0 +1,59 @@
+package revai
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeRange is a range of media time.
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Duration returns the length of the range.
+func (r TimeRange) Duration() time.Duration {
+	return r.End - r.Start
+}
+
+// MergeTimeRanges returns a copy of ranges sorted by start, with ranges
+// overlapping or closer than gap merged.
+func MergeTimeRanges(ranges []TimeRange, gap time.Duration) []TimeRange {
+	sorted := append([]TimeRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var merged []TimeRange
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End+gap {
+			if r.End > merged[n-1].End {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// TimeRange returns the time range of the element.
+func (e Element) TimeRange() TimeRange {
+	return TimeRange{Start: secondsToDuration(e.Ts), End: secondsToDuration(e.EndTs)}
+}
+
+// TimeRange returns the time range of the text elements of the monologue,
+// or a zero range when it has none.
+func (m Monologue) TimeRange() TimeRange {
+	var r TimeRange
+	found := false
+	for _, element := range m.Elements {
+		if element.Type != ElementText {
+			continue
+		}
+		if !found {
+			r.Start = secondsToDuration(element.Ts)
+			found = true
+		}
+		r.End = secondsToDuration(element.EndTs)
+	}
+	return r
+}
@@ -0,0 +1,41 @@
+package revai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonologue_TimeRange(t *testing.T) {
+	r := testTranscript.Monologues[0].TimeRange()
+	assert.Equal(t, TimeRange{Start: 500 * time.Millisecond, End: 2100 * time.Millisecond}, r)
+	assert.Equal(t, 1600*time.Millisecond, r.Duration())
+
+	assert.Equal(t, TimeRange{Start: time.Second, End: 1200 * time.Millisecond}, testTranscript.Monologues[0].Elements[3].TimeRange())
+	assert.Equal(t, TimeRange{}, Monologue{}.TimeRange())
+}
+
+func TestMergeTimeRanges(t *testing.T) {
+	ranges := []TimeRange{
+		{Start: 5 * time.Second, End: 6 * time.Second},
+		{Start: 0, End: 2 * time.Second},
+		{Start: time.Second, End: 1500 * time.Millisecond},
+		{Start: 2100 * time.Millisecond, End: 3 * time.Second},
+	}
+
+	assert.Equal(t, []TimeRange{
+		{Start: 0, End: 2 * time.Second},
+		{Start: 2100 * time.Millisecond, End: 3 * time.Second},
+		{Start: 5 * time.Second, End: 6 * time.Second},
+	}, MergeTimeRanges(ranges, 0))
+
+	// ranges closer than the gap are merged.
+	assert.Equal(t, []TimeRange{
+		{Start: 0, End: 3 * time.Second},
+		{Start: 5 * time.Second, End: 6 * time.Second},
+	}, MergeTimeRanges(ranges, 200*time.Millisecond))
+
+	assert.Equal(t, 5*time.Second, ranges[0].Start)
+	assert.Empty(t, MergeTimeRanges(nil, 0))
+}
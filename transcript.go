@@ -23,13 +23,25 @@ type Monologue struct {
 	Elements []Element `json:"elements"`
 }
 
+// ElementType is the type of a Rev.ai element.
+type ElementType string
+
+const (
+	// ElementText is a word.
+	ElementText ElementType = "text"
+	// ElementPunct is punctuation or whitespace, usually without timestamps.
+	ElementPunct ElementType = "punct"
+	// ElementUnknown is a non-speech tag such as <inaudible> or <laugh>.
+	ElementUnknown ElementType = "unknown"
+)
+
 // Element represents a Rev.ai element
 type Element struct {
-	Type       string  `json:"type"`
-	Value      string  `json:"value"`
-	Ts         float64 `json:"ts"`
-	EndTs      float64 `json:"end_ts"`
-	Confidence float64 `json:"confidence"`
+	Type       ElementType `json:"type"`
+	Value      string      `json:"value"`
+	Ts         float64     `json:"ts"`
+	EndTs      float64     `json:"end_ts"`
+	Confidence float64     `json:"confidence"`
 }
 
 // GetTranscriptParams specifies the parameters to the
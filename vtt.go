@@ -0,0 +1,359 @@
+package revai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const vttHeader = "WEBVTT"
+
+// vttTags lists the WebVTT cue text tags that are not supported by SubRip.
+var vttTags = map[string]bool{"c": true, "v": true, "lang": true, "ruby": true, "rt": true}
+
+// DecodeVTT parses a WebVTT caption file including cue identifiers, cue settings,
+// NOTE, STYLE and REGION blocks. Cue text is kept as written, use Cue.PlainText
+// for the text without markup.
+func DecodeVTT(r io.Reader) (*CaptionFile, error) {
+	blocks, err := splitCaptionBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blocks) == 0 || !isVTTHeader(blocks[0][0]) {
+		return nil, errors.New("missing webvtt header")
+	}
+
+	f := &CaptionFile{
+		Format: CaptionFormatVTT,
+		Header: strings.Join(append([]string{blocks[0][0][len(vttHeader):]}, blocks[0][1:]...), "\n"),
+	}
+
+	for _, block := range blocks[1:] {
+		if t, text, ok := parseVTTBlock(block); ok {
+			f.Blocks = append(f.Blocks, CaptionBlock{Type: t, CueIndex: len(f.Cues), Text: text})
+			continue
+		}
+
+		timing := 0
+		for timing < len(block) && !strings.Contains(block[timing], "-->") {
+			timing++
+		}
+
+		// blocks that are not cues are ignored.
+		if timing > 1 || timing == len(block) {
+			continue
+		}
+
+		cue, err := parseCueTiming(block[timing])
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing vtt cue %w", err)
+		}
+
+		if timing == 1 {
+			cue.ID = block[0]
+		}
+		cue.Lines = block[timing+1:]
+
+		f.Cues = append(f.Cues, cue)
+	}
+
+	return f, nil
+}
+
+func isVTTHeader(line string) bool {
+	if !strings.HasPrefix(line, vttHeader) {
+		return false
+	}
+	rest := line[len(vttHeader):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// parseVTTBlock parses a NOTE, STYLE or REGION block.
+func parseVTTBlock(block []string) (CaptionBlockType, string, bool) {
+	for _, t := range []CaptionBlockType{CaptionBlockNote, CaptionBlockStyle, CaptionBlockRegion} {
+		first := block[0]
+		if !strings.HasPrefix(first, string(t)) {
+			continue
+		}
+
+		rest := first[len(t):]
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			continue
+		}
+
+		lines := block[1:]
+		if rest = strings.TrimSpace(rest); rest != "" {
+			lines = append([]string{rest}, lines...)
+		}
+
+		return t, strings.Join(lines, "\n"), true
+	}
+
+	return "", "", false
+}
+
+// EncodeVTT writes f to w as a WebVTT caption file.
+// Single line NOTE blocks are written on the same line as NOTE.
+func EncodeVTT(w io.Writer, f *CaptionFile) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString(vttHeader + f.Header + "\n"); err != nil {
+		return err
+	}
+
+	next := 0
+	writeBlocks := func(cueIndex int) error {
+		for ; next < len(f.Blocks) && f.Blocks[next].CueIndex <= cueIndex; next++ {
+			b := f.Blocks[next]
+
+			sep := " "
+			if b.Type != CaptionBlockNote || strings.Contains(b.Text, "\n") {
+				sep = "\n"
+			}
+			if b.Text == "" {
+				sep = ""
+			}
+
+			if _, err := fmt.Fprintf(bw, "\n%s%s%s\n", b.Type, sep, b.Text); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i, cue := range f.Cues {
+		if err := writeBlocks(i); err != nil {
+			return err
+		}
+
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+
+		if cue.ID != "" {
+			if _, err := bw.WriteString(cue.ID + "\n"); err != nil {
+				return err
+			}
+		}
+
+		timing := formatCueTimestamp(cue.Start, ".") + " --> " + formatCueTimestamp(cue.End, ".")
+		for _, s := range cue.Settings {
+			timing += " " + s.Name
+			if s.Value != "" {
+				timing += ":" + s.Value
+			}
+		}
+
+		if _, err := bw.WriteString(timing + "\n"); err != nil {
+			return err
+		}
+
+		for _, line := range cue.Lines {
+			if _, err := bw.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeBlocks(len(f.Cues)); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// Convert returns a copy of f in the given format. Converting to SubRip drops
+// the WebVTT header, blocks, cue settings and any markup SubRip does not support.
+// Converting to WebVTT drops font tags and escapes the cue text.
+func (f *CaptionFile) Convert(format CaptionFormat) (*CaptionFile, error) {
+	if format != CaptionFormatSRT && format != CaptionFormatVTT {
+		return nil, fmt.Errorf("unsupported caption format %q", format)
+	}
+
+	out := &CaptionFile{Format: format}
+	if format == f.Format {
+		out.Header = f.Header
+		out.Blocks = append(out.Blocks, f.Blocks...)
+	}
+
+	for _, cue := range f.Cues {
+		c := cue
+		c.Lines = make([]string, len(cue.Lines))
+		c.Settings = append([]CueSetting(nil), cue.Settings...)
+
+		for i, line := range cue.Lines {
+			switch {
+			case format == f.Format:
+				c.Lines[i] = line
+			case format == CaptionFormatSRT:
+				c.Lines[i] = mapCueText(line, func(tag string) bool {
+					name := cueTagName(tag)
+					return !vttTags[name] && !isCueTimestampTag(name)
+				}, unescapeCueText)
+			default:
+				c.Lines[i] = mapCueText(line, func(tag string) bool {
+					return cueTagName(tag) != "font"
+				}, escapeCueText)
+			}
+		}
+
+		if format == CaptionFormatSRT {
+			c.Settings = nil
+			if _, err := strconv.Atoi(c.ID); err != nil {
+				c.ID = ""
+			}
+		}
+
+		out.Cues = append(out.Cues, c)
+	}
+
+	return out, nil
+}
+
+func isCueTimestampTag(name string) bool {
+	return name != "" && name[0] >= '0' && name[0] <= '9'
+}
+
+// vttSpeakerPositions are the cue settings speakers are placed with when
+// positioning speakers, in order of first appearance.
+var vttSpeakerPositions = [][]CueSetting{
+	{{Name: "line", Value: "85%"}, {Name: "position", Value: "10%"}, {Name: "align", Value: "start"}},
+	{{Name: "line", Value: "85%"}, {Name: "position", Value: "90%"}, {Name: "align", Value: "end"}},
+	{{Name: "line", Value: "10%"}, {Name: "position", Value: "50%"}, {Name: "align", Value: "center"}},
+}
+
+// vttSpeakerColors are the colors speakers are styled with when
+// positioning speakers, in order of first appearance.
+var vttSpeakerColors = []string{"white", "yellow", "cyan", "lime", "magenta"}
+
+// VTTOptions specifies how Transcript.VTT creates WebVTT captions.
+type VTTOptions struct {
+	// Style is the caption style profile cues are segmented with.
+	// It defaults to NetflixCaptionStyle.
+	Style *CaptionStyle
+
+	// SpeakerNames maps Monologue.Speaker to the name used in voice tags.
+	// Speakers without a name are called "Speaker N".
+	SpeakerNames map[int]string
+
+	// PositionSpeakers places each speaker's cues in a different position on
+	// screen and adds a STYLE block giving each voice its own color.
+	PositionSpeakers bool
+
+	// SpeakerPositions overrides the cue settings used for a speaker
+	// when positioning speakers.
+	SpeakerPositions map[int][]CueSetting
+
+	// SpeakerColors overrides the CSS color used for a speaker
+	// when positioning speakers.
+	SpeakerColors map[int]string
+
+	// Karaoke adds a timestamp tag before each word of the cue text
+	// so players can highlight words as they are spoken.
+	Karaoke bool
+
+	// FrameRate snaps cue and word times to its frames when set, and
+	// StartTimecode is added to them, as with SnapCues.
+	FrameRate     FrameRate
+	StartTimecode Timecode
+}
+
+// VTT creates WebVTT captions from the transcript where each cue starts with
+// a voice tag naming its speaker. With Karaoke set each text element is
+// preceded by a timestamp tag of its Ts, punctuation stays with the word before it.
+func (t *Transcript) VTT(opts *VTTOptions) *CaptionFile {
+	if opts == nil {
+		opts = &VTTOptions{}
+	}
+
+	segments := reflow(transcriptWords(t), opts.Style.withDefaults())
+	segments = cueTiming{rate: opts.FrameRate, start: opts.StartTimecode}.segments(segments)
+
+	f := &CaptionFile{Format: CaptionFormatVTT}
+
+	// speakers are numbered by first appearance to pick positions and colors.
+	order := map[int]int{}
+	var speakers []int
+	for _, seg := range segments {
+		if _, ok := order[seg.speaker]; !ok {
+			order[seg.speaker] = len(speakers)
+			speakers = append(speakers, seg.speaker)
+		}
+	}
+
+	if opts.PositionSpeakers && len(speakers) > 0 {
+		var css []string
+		for _, speaker := range speakers {
+			color, ok := opts.SpeakerColors[speaker]
+			if !ok {
+				color = vttSpeakerColors[order[speaker]%len(vttSpeakerColors)]
+			}
+			css = append(css, fmt.Sprintf("::cue(v[voice=\"%s\"]) {\n  color: %s;\n}",
+				cssString(opts.speakerName(speaker)), color))
+		}
+		f.Blocks = append(f.Blocks, CaptionBlock{Type: CaptionBlockStyle, Text: strings.Join(css, "\n")})
+	}
+
+	for _, seg := range segments {
+		cue := seg.cue
+
+		lines := make([]string, len(cue.Lines))
+		for i, line := range cue.Lines {
+			lines[i] = escapeCueText(line)
+		}
+		if opts.Karaoke {
+			lines = karaokeVTTLines(seg)
+		}
+		if len(lines) > 0 {
+			lines[0] = "<v " + escapeCueText(opts.speakerName(seg.speaker)) + ">" + lines[0]
+		}
+		cue.Lines = lines
+
+		if opts.PositionSpeakers {
+			settings, ok := opts.SpeakerPositions[seg.speaker]
+			if !ok {
+				settings = vttSpeakerPositions[order[seg.speaker]%len(vttSpeakerPositions)]
+			}
+			cue.Settings = append([]CueSetting(nil), settings...)
+		}
+
+		f.Cues = append(f.Cues, cue)
+	}
+
+	return f
+}
+
+func (o *VTTOptions) speakerName(speaker int) string {
+	if name, ok := o.SpeakerNames[speaker]; ok && name != "" {
+		return name
+	}
+	return "Speaker " + strconv.Itoa(speaker)
+}
+
+// cssString escapes s for use inside a double quoted CSS string.
+func cssString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `).Replace(s)
+}
+
+// karaokeVTTLines returns the cue lines of seg with a timestamp tag before each
+// word that starts during the cue.
+func karaokeVTTLines(seg captionSegment) []string {
+	var lines []string
+
+	for _, words := range seg.lines() {
+		texts := make([]string, len(words))
+		for i, w := range words {
+			texts[i] = escapeCueText(w.text)
+			if w.timed && w.start > seg.cue.Start && w.start < seg.cue.End {
+				texts[i] = "<" + formatCueTimestamp(w.start, ".") + ">" + texts[i]
+			}
+		}
+		lines = append(lines, strings.Join(texts, " "))
+	}
+
+	return lines
+}
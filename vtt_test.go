@@ -0,0 +1,213 @@
+package revai
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testVTT = `WEBVTT - Test captions
+Kind: captions
+
+NOTE
+Created for testing
+by the test suite
+
+STYLE
+::cue(v[voice="Jane"]) {
+  color: yellow;
+}
+
+intro
+00:00:00.500 --> 00:00:02.100 line:90% align:start
+<v.loud Jane>Hello, my name is <b>Jane</b>.</v>
+
+00:00:02.500 --> 00:00:03.400
+<v Bob>Nice to meet you.
+Q&amp;A starts <00:00:03.000>now.
+
+NOTE the end
+`
+
+func TestDecodeVTT(t *testing.T) {
+	f, err := DecodeVTT(strings.NewReader(testVTT))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, CaptionFormatVTT, f.Format)
+	assert.Equal(t, " - Test captions\nKind: captions", f.Header)
+	assert.Equal(t, []CaptionBlock{
+		{Type: CaptionBlockNote, CueIndex: 0, Text: "Created for testing\nby the test suite"},
+		{Type: CaptionBlockStyle, CueIndex: 0, Text: "::cue(v[voice=\"Jane\"]) {\n  color: yellow;\n}"},
+		{Type: CaptionBlockNote, CueIndex: 2, Text: "the end"},
+	}, f.Blocks)
+
+	if !assert.Len(t, f.Cues, 2) {
+		return
+	}
+
+	first := f.Cues[0]
+	assert.Equal(t, "intro", first.ID)
+	assert.Equal(t, 500*time.Millisecond, first.Start)
+	assert.Equal(t, 2100*time.Millisecond, first.End)
+	assert.Equal(t, []CueSetting{{Name: "line", Value: "90%"}, {Name: "align", Value: "start"}}, first.Settings)
+	assert.Equal(t, "Jane", first.Voice())
+	assert.Equal(t, "Hello, my name is Jane.", first.PlainText())
+
+	line, ok := first.Setting("line")
+	assert.True(t, ok)
+	assert.Equal(t, "90%", line)
+
+	second := f.Cues[1]
+	assert.Equal(t, "Bob", second.Voice())
+	assert.Equal(t, "Nice to meet you.\nQ&A starts now.", second.PlainText())
+}
+
+func TestEncodeVTT(t *testing.T) {
+	f, err := DecodeVTT(strings.NewReader(testVTT))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := EncodeVTT(buf, f); err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, testVTT, buf.String())
+}
+
+func TestCaptionFile_Convert(t *testing.T) {
+	f, err := DecodeVTT(strings.NewReader(testVTT))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	srt, err := f.Convert(CaptionFormatSRT)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Empty(t, srt.Blocks)
+	assert.Nil(t, srt.Cues[0].Settings)
+	assert.Equal(t, "", srt.Cues[0].ID)
+	assert.Equal(t, []string{"Hello, my name is <b>Jane</b>."}, srt.Cues[0].Lines)
+	assert.Equal(t, []string{"Nice to meet you.", "Q&A starts now."}, srt.Cues[1].Lines)
+
+	vtt, err := srt.Convert(CaptionFormatVTT)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, []string{"Nice to meet you.", "Q&amp;A starts now."}, vtt.Cues[1].Lines)
+
+	_, err = f.Convert("ass")
+	assert.Error(t, err)
+}
+
+func TestCaption_Decode(t *testing.T) {
+	f, err := (&Caption{Value: testVTT}).Decode()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, CaptionFormatVTT, f.Format)
+
+	f, err = (&Caption{Value: testSRT}).Decode()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, CaptionFormatSRT, f.Format)
+}
+
+func TestDecodeVTT_MissingHeader(t *testing.T) {
+	_, err := DecodeVTT(strings.NewReader("WEBVTTX\n\n00:01.000 --> 00:02.000\nhi\n"))
+	assert.Error(t, err)
+}
+
+func TestTranscript_VTT(t *testing.T) {
+	f := testTranscript.VTT(&VTTOptions{
+		SpeakerNames: map[int]string{0: "Jane"},
+	})
+
+	assert.Empty(t, f.Blocks)
+	if !assert.Len(t, f.Cues, 2) {
+		return
+	}
+
+	assert.Equal(t, []string{"<v Jane>Hello, my name is Jane."}, f.Cues[0].Lines)
+	assert.Equal(t, "Jane", f.Cues[0].Voice())
+	assert.Equal(t, "Speaker 1", f.Cues[1].Voice())
+	assert.Nil(t, f.Cues[0].Settings)
+}
+
+func TestTranscript_VTTTimecode(t *testing.T) {
+	start, _ := ParseTimecode("01:00:00:00", FrameRate25)
+	f := testTranscript.VTT(&VTTOptions{FrameRate: FrameRate25, StartTimecode: start, Karaoke: true})
+
+	if assert.Len(t, f.Cues, 2) {
+		assert.Equal(t, time.Hour+520*time.Millisecond, f.Cues[0].Start)
+		assert.Equal(t, time.Hour+2400*time.Millisecond, f.Cues[0].End)
+		assert.Contains(t, f.Cues[0].Lines[0], "<01:00:01.000>my")
+	}
+}
+
+func TestTranscript_VTTPositionSpeakers(t *testing.T) {
+	f := testTranscript.VTT(&VTTOptions{
+		SpeakerNames:     map[int]string{0: "Jane", 1: `Bob "B"`},
+		PositionSpeakers: true,
+		SpeakerColors:    map[int]string{1: "#ff0"},
+	})
+
+	assert.Equal(t, []CaptionBlock{{
+		Type: CaptionBlockStyle,
+		Text: "::cue(v[voice=\"Jane\"]) {\n  color: white;\n}\n::cue(v[voice=\"Bob \\\"B\\\"\"]) {\n  color: #ff0;\n}",
+	}}, f.Blocks)
+
+	align, _ := f.Cues[0].Setting("align")
+	assert.Equal(t, "start", align)
+	align, _ = f.Cues[1].Setting("align")
+	assert.Equal(t, "end", align)
+
+	buf := new(bytes.Buffer)
+	if err := EncodeVTT(buf, f); err != nil {
+		t.Error(err)
+		return
+	}
+
+	decoded, err := DecodeVTT(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, f.Blocks, decoded.Blocks)
+	for i, cue := range decoded.Cues {
+		assert.Equal(t, f.Cues[i].Lines, cue.Lines)
+		assert.Equal(t, f.Cues[i].Settings, cue.Settings)
+	}
+}
+
+func TestTranscript_VTTKaraoke(t *testing.T) {
+	f := testTranscript.VTT(&VTTOptions{Karaoke: true})
+
+	if !assert.Len(t, f.Cues, 2) {
+		return
+	}
+
+	assert.Equal(t, []string{
+		"<v Speaker 0>Hello, <00:00:01.000>my <00:00:01.200>name <00:00:01.500>is <00:00:01.700>Jane.",
+	}, f.Cues[0].Lines)
+	assert.Equal(t, "Hello, my name is Jane.", f.Cues[0].PlainText())
+}
@@ -0,0 +1,203 @@
+package wav
+
+import (
+	"math"
+	"time"
+
+	revai "github.com/threeaccents/revai-go"
+)
+
+// Fill is what redacted audio is replaced with.
+type Fill int
+
+const (
+	// Silence replaces redacted audio with silence.
+	Silence Fill = iota
+	// Tone replaces redacted audio with a sine tone, a bleep.
+	Tone
+)
+
+// RedactOptions specifies how audio is redacted.
+type RedactOptions struct {
+	Fill Fill
+
+	// ToneFrequency is the frequency of the tone in hertz. It defaults to
+	// 1000.
+	ToneFrequency float64
+
+	// ToneLevel is the amplitude of the tone from 0 to 1. It defaults to 0.25.
+	ToneLevel float64
+
+	// Ramp is the time taken to fade to the fill before a range and back
+	// after it, avoiding clicks. It defaults to 5 milliseconds.
+	Ramp time.Duration
+}
+
+func (o *RedactOptions) withDefaults() RedactOptions {
+	opts := RedactOptions{ToneFrequency: 1000, ToneLevel: 0.25, Ramp: 5 * time.Millisecond}
+
+	if o == nil {
+		return opts
+	}
+
+	opts.Fill = o.Fill
+	if o.ToneFrequency > 0 {
+		opts.ToneFrequency = o.ToneFrequency
+	}
+	if o.ToneLevel > 0 {
+		opts.ToneLevel = o.ToneLevel
+	}
+	if o.Ramp > 0 {
+		opts.Ramp = o.Ramp
+	}
+
+	return opts
+}
+
+// Redact returns a copy of the audio with the time ranges replaced by
+// silence or a tone. Audio within the ranges is fully replaced, the ramps
+// are outside of them.
+func (a *Audio) Redact(ranges []revai.TimeRange, opts *RedactOptions) *Audio {
+	o := opts.withDefaults()
+
+	out := &Audio{Format: a.Format, Data: append([]byte{}, a.Data...)}
+	ramp := int(math.Round(o.Ramp.Seconds() * float64(a.SampleRate)))
+
+	for _, r := range revai.MergeTimeRanges(ranges, 0) {
+		start, end := a.frameAt(r.Start), a.frameAt(r.End)
+
+		for f := start - ramp; f < end+ramp; f++ {
+			if f < 0 || f >= out.Frames() {
+				continue
+			}
+
+			gain := 1.0
+			switch {
+			case f < start:
+				gain = (float64(f-start+ramp) + 0.5) / float64(ramp)
+			case f >= end:
+				gain = (float64(end+ramp-f) - 0.5) / float64(ramp)
+			}
+
+			fill := 0.0
+			if o.Fill == Tone {
+				fill = o.ToneLevel * math.Sin(2*math.Pi*o.ToneFrequency*float64(f-start)/float64(a.SampleRate))
+			}
+
+			for c := 0; c < a.Channels; c++ {
+				out.SetSample(f, c, out.Sample(f, c)*(1-gain)+fill*gain)
+			}
+		}
+	}
+
+	return out
+}
+
+// ClipOptions specifies how clips are cut.
+type ClipOptions struct {
+	// Padding is added before and after each range.
+	Padding time.Duration
+
+	// Crossfade is the length of the linear crossfade between clips.
+	Crossfade time.Duration
+}
+
+// Slice returns the audio within r. The slice shares its samples with a
+// rather than copying them, so changes to either are seen by both.
+func (a *Audio) Slice(r revai.TimeRange) *Audio {
+	start, end := a.frameAt(r.Start), a.frameAt(r.End)
+	if end < start {
+		end = start
+	}
+	size := a.frameSize()
+	return &Audio{Format: a.Format, Data: a.Data[start*size : end*size : end*size]}
+}
+
+// Clip returns the audio of the time ranges joined in the order given, such
+// as the ranges of transcript elements and monologues for a highlight reel.
+// Ranges which overlap the range before them once padded are merged.
+func (a *Audio) Clip(ranges []revai.TimeRange, opts *ClipOptions) *Audio {
+	if opts == nil {
+		opts = &ClipOptions{}
+	}
+
+	var padded []revai.TimeRange
+	for _, r := range ranges {
+		r.Start -= opts.Padding
+		r.End += opts.Padding
+		if n := len(padded); n > 0 && r.Start <= padded[n-1].End && r.End >= padded[n-1].Start {
+			if r.Start < padded[n-1].Start {
+				padded[n-1].Start = r.Start
+			}
+			if r.End > padded[n-1].End {
+				padded[n-1].End = r.End
+			}
+			continue
+		}
+		padded = append(padded, r)
+	}
+
+	size := a.frameSize()
+	fade := int(math.Round(opts.Crossfade.Seconds() * float64(a.SampleRate)))
+
+	out := &Audio{Format: a.Format, Data: []byte{}}
+	for _, r := range padded {
+		clip := a.Slice(r)
+		if clip.Frames() == 0 {
+			continue
+		}
+
+		n := fade
+		if n > out.Frames() {
+			n = out.Frames()
+		}
+		if n > clip.Frames() {
+			n = clip.Frames()
+		}
+
+		offset := out.Frames() - n
+		for f := 0; f < n; f++ {
+			t := (float64(f) + 0.5) / float64(n)
+			for c := 0; c < a.Channels; c++ {
+				out.SetSample(offset+f, c, out.Sample(offset+f, c)*(1-t)+clip.Sample(f, c)*t)
+			}
+		}
+
+		out.Data = append(out.Data, clip.Data[n*size:]...)
+	}
+
+	return out
+}
+
+// Quietest returns the middle of the quietest window of audio within r, the
+// window with the least energy, such as a pause to cut the audio at. Windows
+// longer than r are shortened to it.
+func (a *Audio) Quietest(r revai.TimeRange, window time.Duration) time.Duration {
+	start, end := a.frameAt(r.Start), a.frameAt(r.End)
+	size := a.frameAt(window)
+	if size > end-start {
+		size = end - start
+	}
+	if size <= 0 {
+		return a.timeAt(start)
+	}
+
+	// energy[i] is the energy of the frames from start to start+i.
+	energy := make([]float64, end-start+1)
+	for f := start; f < end; f++ {
+		var e float64
+		for ch := 0; ch < a.Channels; ch++ {
+			s := a.Sample(f, ch)
+			e += s * s
+		}
+		energy[f-start+1] = energy[f-start] + e
+	}
+
+	best := 0
+	for i := 1; i+size < len(energy); i++ {
+		if energy[i+size]-energy[i] < energy[best+size]-energy[best] {
+			best = i
+		}
+	}
+	return a.timeAt(start + best + size/2)
+}
@@ -0,0 +1,301 @@
+// Package wav reads and writes PCM WAV audio and edits it using the time
+// ranges of Rev.ai transcripts, such as to bleep redacted words or cut clips.
+package wav
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+)
+
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xfffe
+)
+
+// ErrUnsupportedFormat is returned when decoding WAV audio which is not 8,
+// 16, 24 or 32 bit PCM or 32 bit float.
+var ErrUnsupportedFormat = errors.New("unsupported wav format")
+
+// Format is the sample format of audio.
+type Format struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+
+	// Float is set for 32 bit IEEE float samples.
+	Float bool
+}
+
+func (f Format) valid() bool {
+	if f.SampleRate <= 0 || f.Channels <= 0 {
+		return false
+	}
+	if f.Float {
+		return f.BitsPerSample == 32
+	}
+	switch f.BitsPerSample {
+	case 8, 16, 24, 32:
+		return true
+	}
+	return false
+}
+
+func (f Format) frameSize() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// Audio is WAV audio held in memory as its raw interleaved sample data.
+type Audio struct {
+	Format
+	Data []byte
+}
+
+// New returns silent audio of the format and duration.
+func New(format Format, d time.Duration) (*Audio, error) {
+	if !format.valid() {
+		return nil, ErrUnsupportedFormat
+	}
+
+	a := &Audio{Format: format}
+	a.Data = make([]byte, a.frameAt(d)*format.frameSize())
+	if format.BitsPerSample == 8 {
+		for i := range a.Data {
+			a.Data[i] = 0x80
+		}
+	}
+	return a, nil
+}
+
+// Frames returns the number of sample frames, a sample for each channel.
+func (a *Audio) Frames() int {
+	return len(a.Data) / a.frameSize()
+}
+
+// Duration returns the length of the audio.
+func (a *Audio) Duration() time.Duration {
+	return a.timeAt(a.Frames())
+}
+
+// frameAt returns the frame at d, limited to the audio.
+func (a *Audio) frameAt(d time.Duration) int {
+	frame := int(math.Round(d.Seconds() * float64(a.SampleRate)))
+	if frame < 0 {
+		return 0
+	}
+	if a.Data != nil && frame > a.Frames() {
+		return a.Frames()
+	}
+	return frame
+}
+
+// timeAt returns the time of a frame.
+func (a *Audio) timeAt(frame int) time.Duration {
+	return time.Duration(float64(frame) / float64(a.SampleRate) * float64(time.Second))
+}
+
+// Sample returns the sample of a channel in a frame scaled to [-1, 1].
+func (a *Audio) Sample(frame, channel int) float64 {
+	size := a.BitsPerSample / 8
+	b := a.Data[(frame*a.Channels+channel)*size:]
+
+	switch {
+	case a.Float:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case size == 1:
+		return (float64(b[0]) - 128) / 128
+	case size == 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case size == 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+// SetSample sets the sample of a channel in a frame, clipping it to [-1, 1].
+func (a *Audio) SetSample(frame, channel int, v float64) {
+	size := a.BitsPerSample / 8
+	b := a.Data[(frame*a.Channels+channel)*size:]
+
+	v = math.Max(-1, math.Min(1, v))
+	scale := func(bits uint) int64 {
+		max := float64(int64(1)<<(bits-1) - 1)
+		return int64(math.Round(v * max))
+	}
+
+	switch {
+	case a.Float:
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+	case size == 1:
+		b[0] = byte(scale(8) + 128)
+	case size == 2:
+		binary.LittleEndian.PutUint16(b, uint16(scale(16)))
+	case size == 3:
+		s := scale(24)
+		b[0], b[1], b[2] = byte(s), byte(s>>8), byte(s>>16)
+	default:
+		binary.LittleEndian.PutUint32(b, uint32(scale(32)))
+	}
+}
+
+// Decode reads WAV audio. Chunks other than the format and data chunks are
+// skipped.
+func Decode(r io.Reader) (*Audio, error) {
+	br := bufio.NewReader(r)
+
+	var header [12]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("failed reading wav %w", err)
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WAVE" {
+		return nil, errors.New("invalid wav header")
+	}
+	riffSize := binary.LittleEndian.Uint32(header[4:])
+
+	var (
+		a      Audio
+		hasFmt bool
+	)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(br, chunk[:]); err != nil {
+			if err == io.EOF {
+				return nil, errors.New("invalid wav, missing data chunk")
+			}
+			return nil, fmt.Errorf("failed reading wav %w", err)
+		}
+		id, size := string(chunk[:4]), binary.LittleEndian.Uint32(chunk[4:])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1<<16 {
+				return nil, errors.New("invalid wav format chunk")
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(br, b); err != nil {
+				return nil, fmt.Errorf("failed reading wav %w", err)
+			}
+			format, err := parseFormat(b[:size])
+			if err != nil {
+				return nil, err
+			}
+			a.Format = format
+			hasFmt = true
+		case "data":
+			if !hasFmt {
+				return nil, errors.New("invalid wav, data before format chunk")
+			}
+
+			// streamed files may not know the data size, their data is read
+			// to the end. An empty data chunk is only streamed when the RIFF
+			// size is unknown too, otherwise chunks may follow it.
+			var (
+				data []byte
+				err  error
+			)
+			unknown := riffSize == 0 || riffSize == math.MaxUint32
+			if size == math.MaxUint32 || (size == 0 && unknown) {
+				var buf bytes.Buffer
+				_, err = buf.ReadFrom(br)
+				data = buf.Bytes()
+			} else {
+				data = make([]byte, size)
+				var n int
+				n, err = io.ReadFull(br, data)
+				data = data[:n]
+				if err == io.ErrUnexpectedEOF {
+					err = nil
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed reading wav %w", err)
+			}
+
+			a.Data = data[:len(data)/a.frameSize()*a.frameSize()]
+			return &a, nil
+		default:
+			if _, err := io.CopyN(ioutil.Discard, br, int64(size)+int64(size%2)); err != nil {
+				return nil, fmt.Errorf("failed reading wav %w", err)
+			}
+		}
+	}
+}
+
+func parseFormat(b []byte) (Format, error) {
+	tag := binary.LittleEndian.Uint16(b)
+	f := Format{
+		Channels:      int(binary.LittleEndian.Uint16(b[2:])),
+		SampleRate:    int(binary.LittleEndian.Uint32(b[4:])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(b[14:])),
+	}
+
+	// extensible formats hold the format tag in the first bytes of their
+	// sub format.
+	if tag == formatExtensible && len(b) >= 26 {
+		tag = binary.LittleEndian.Uint16(b[24:])
+	}
+
+	switch tag {
+	case formatPCM:
+	case formatFloat:
+		f.Float = true
+	default:
+		return f, ErrUnsupportedFormat
+	}
+
+	if !f.valid() {
+		return f, ErrUnsupportedFormat
+	}
+	return f, nil
+}
+
+// Encode writes the audio to w as a WAV file.
+func (a *Audio) Encode(w io.Writer) error {
+	if !a.valid() {
+		return ErrUnsupportedFormat
+	}
+
+	tag := uint16(formatPCM)
+	if a.Float {
+		tag = formatFloat
+	}
+
+	pad := len(a.Data) % 2
+
+	bw := bufio.NewWriter(w)
+	le := binary.LittleEndian
+
+	header := make([]byte, 44)
+	copy(header, "RIFF")
+	le.PutUint32(header[4:], uint32(36+len(a.Data)+pad))
+	copy(header[8:], "WAVEfmt ")
+	le.PutUint32(header[16:], 16)
+	le.PutUint16(header[20:], tag)
+	le.PutUint16(header[22:], uint16(a.Channels))
+	le.PutUint32(header[24:], uint32(a.SampleRate))
+	le.PutUint32(header[28:], uint32(a.SampleRate*a.frameSize()))
+	le.PutUint16(header[32:], uint16(a.frameSize()))
+	le.PutUint16(header[34:], uint16(a.BitsPerSample))
+	copy(header[36:], "data")
+	le.PutUint32(header[40:], uint32(len(a.Data)))
+
+	bw.Write(header)
+	bw.Write(a.Data)
+	if pad == 1 {
+		bw.WriteByte(0)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed writing wav %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	revai "github.com/threeaccents/revai-go"
+)
+
+// ramp returns audio of the format where each sample is its frame number
+// scaled to [-1, 1] over the audio, negated on odd channels.
+func ramp(t *testing.T, format Format, d time.Duration) *Audio {
+	a, err := New(format, d)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for f := 0; f < a.Frames(); f++ {
+		for c := 0; c < a.Channels; c++ {
+			v := float64(f)/float64(a.Frames())*2 - 1
+			if c%2 == 1 {
+				v = -v
+			}
+			a.SetSample(f, c, v)
+		}
+	}
+	return a
+}
+
+func TestEncodeDecode(t *testing.T) {
+	formats := []Format{
+		{SampleRate: 8000, Channels: 1, BitsPerSample: 8},
+		{SampleRate: 16000, Channels: 2, BitsPerSample: 16},
+		{SampleRate: 44100, Channels: 3, BitsPerSample: 24},
+		{SampleRate: 48000, Channels: 6, BitsPerSample: 32},
+		{SampleRate: 48000, Channels: 2, BitsPerSample: 32, Float: true},
+	}
+
+	for _, format := range formats {
+		a := ramp(t, format, 100*time.Millisecond)
+
+		var buf bytes.Buffer
+		assert.NoError(t, a.Encode(&buf))
+
+		decoded, err := Decode(&buf)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, a.Format, decoded.Format)
+		assert.Equal(t, a.Data, decoded.Data)
+		assert.Equal(t, 100*time.Millisecond, decoded.Duration())
+
+		tolerance := 1.0 / float64(int64(1)<<(format.BitsPerSample-1))
+		assert.InDelta(t, -1, decoded.Sample(0, 0), tolerance)
+		if format.Channels > 1 {
+			assert.InDelta(t, 1, decoded.Sample(0, 1), tolerance)
+		}
+	}
+}
+
+func TestDecode_Chunks(t *testing.T) {
+	le := binary.LittleEndian
+
+	// an extensible format chunk, a list chunk of odd size and a data chunk
+	// of unknown size.
+	var b bytes.Buffer
+	b.WriteString("RIFF\x00\x00\x00\x00WAVE")
+	b.WriteString("LIST\x03\x00\x00\x00abc\x00")
+
+	fmtChunk := make([]byte, 40)
+	le.PutUint16(fmtChunk, formatExtensible)
+	le.PutUint16(fmtChunk[2:], 1)
+	le.PutUint32(fmtChunk[4:], 8000)
+	le.PutUint32(fmtChunk[8:], 16000)
+	le.PutUint16(fmtChunk[12:], 2)
+	le.PutUint16(fmtChunk[14:], 16)
+	le.PutUint16(fmtChunk[24:], formatPCM)
+	b.WriteString("fmt \x28\x00\x00\x00")
+	b.Write(fmtChunk)
+
+	b.WriteString("data\xff\xff\xff\xff")
+	b.Write([]byte{0x00, 0x40, 0x00, 0xc0, 0x01})
+
+	a, err := Decode(&b)
+	if assert.NoError(t, err) {
+		assert.Equal(t, Format{SampleRate: 8000, Channels: 1, BitsPerSample: 16}, a.Format)
+		assert.Equal(t, 2, a.Frames())
+		assert.Equal(t, 0.5, a.Sample(0, 0))
+		assert.Equal(t, -0.5, a.Sample(1, 0))
+	}
+
+	// an empty data chunk of a file of known size is followed by other chunks
+	// rather than streamed.
+	var empty bytes.Buffer
+	empty.WriteString("RIFF\x30\x00\x00\x00WAVE")
+	empty.WriteString("fmt \x10\x00\x00\x00")
+	empty.Write([]byte{1, 0, 1, 0, 0x40, 0x1f, 0, 0, 0x80, 0x3e, 0, 0, 2, 0, 16, 0})
+	empty.WriteString("data\x00\x00\x00\x00")
+	empty.WriteString("LIST\x04\x00\x00\x00abcd")
+	a, err = Decode(&empty)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, a.Frames())
+	}
+
+	_, err = Decode(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVE")))
+	assert.Error(t, err)
+	_, err = Decode(bytes.NewReader([]byte("RIFX\x00\x00\x00\x00WAVE")))
+	assert.Error(t, err)
+
+	alaw := append([]byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00"), 6, 0, 1, 0, 0x40, 0x1f, 0, 0, 0x40, 0x1f, 0, 0, 1, 0, 8, 0)
+	_, err = Decode(bytes.NewReader(alaw))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestAudio_Redact(t *testing.T) {
+	a := ramp(t, Format{SampleRate: 1000, Channels: 2, BitsPerSample: 16}, time.Second)
+	ranges := []revai.TimeRange{{Start: 200 * time.Millisecond, End: 300 * time.Millisecond}}
+
+	silenced := a.Redact(ranges, nil)
+	assert.Equal(t, a.Frames(), silenced.Frames())
+	for f := 200; f < 300; f++ {
+		assert.Equal(t, 0.0, silenced.Sample(f, 0))
+		assert.Equal(t, 0.0, silenced.Sample(f, 1))
+	}
+
+	// the ramps are outside of the range and the rest is unchanged.
+	assert.Equal(t, a.Sample(194, 0), silenced.Sample(194, 0))
+	assert.True(t, math.Abs(silenced.Sample(197, 0)) < math.Abs(a.Sample(197, 0)))
+	assert.Equal(t, a.Sample(305, 1), silenced.Sample(305, 1))
+	assert.Equal(t, a.Sample(900, 1), silenced.Sample(900, 1))
+
+	// the original is unchanged.
+	assert.Equal(t, ramp(t, a.Format, time.Second).Data, a.Data)
+
+	toned := a.Redact(ranges, &RedactOptions{Fill: Tone, ToneFrequency: 250, ToneLevel: 0.5})
+	assert.InDelta(t, 0, toned.Sample(200, 0), 1e-4)
+	assert.InDelta(t, 0.5, toned.Sample(201, 0), 1e-4)
+	assert.InDelta(t, 0.5, toned.Sample(201, 1), 1e-4)
+	assert.InDelta(t, -0.5, toned.Sample(203, 0), 1e-4)
+
+	// ranges past the end of the audio are limited to it.
+	assert.Equal(t, 0.0, a.Redact([]revai.TimeRange{{Start: 900 * time.Millisecond, End: 2 * time.Second}}, nil).Sample(999, 0))
+}
+
+func TestAudio_Clip(t *testing.T) {
+	a := ramp(t, Format{SampleRate: 1000, Channels: 1, BitsPerSample: 16}, time.Second)
+
+	clip := a.Clip([]revai.TimeRange{
+		{Start: 600 * time.Millisecond, End: 700 * time.Millisecond},
+		{Start: 100 * time.Millisecond, End: 150 * time.Millisecond},
+	}, &ClipOptions{Padding: 10 * time.Millisecond})
+
+	assert.Equal(t, 190, clip.Frames())
+	assert.Equal(t, a.Sample(590, 0), clip.Sample(0, 0))
+	assert.Equal(t, a.Sample(709, 0), clip.Sample(119, 0))
+	assert.Equal(t, a.Sample(90, 0), clip.Sample(120, 0))
+
+	// ranges overlapping once padded are merged.
+	merged := a.Clip([]revai.TimeRange{
+		{Start: 100 * time.Millisecond, End: 200 * time.Millisecond},
+		{Start: 210 * time.Millisecond, End: 300 * time.Millisecond},
+	}, &ClipOptions{Padding: 10 * time.Millisecond})
+	assert.Equal(t, 220, merged.Frames())
+
+	faded := a.Clip([]revai.TimeRange{
+		{Start: 0, End: 100 * time.Millisecond},
+		{Start: 500 * time.Millisecond, End: 600 * time.Millisecond},
+	}, &ClipOptions{Crossfade: 20 * time.Millisecond})
+	assert.Equal(t, 180, faded.Frames())
+	assert.InDelta(t, a.Sample(80, 0)*0.975+a.Sample(500, 0)*0.025, faded.Sample(80, 0), 1e-4)
+	assert.Equal(t, a.Sample(520, 0), faded.Sample(100, 0))
+
+	// clips of monologues and elements.
+	monologue := revai.Monologue{Elements: []revai.Element{{Type: "text", Value: "hi", Ts: 0.25, EndTs: 0.5}}}
+	assert.Equal(t, 250, a.Clip([]revai.TimeRange{monologue.TimeRange()}, nil).Frames())
+	assert.Equal(t, 0, a.Clip(nil, nil).Frames())
+}
+
+func TestAudio_Slice(t *testing.T) {
+	a := ramp(t, Format{SampleRate: 1000, Channels: 1, BitsPerSample: 16}, time.Second)
+
+	slice := a.Slice(revai.TimeRange{Start: 200 * time.Millisecond, End: 300 * time.Millisecond})
+	assert.Equal(t, 100, slice.Frames())
+	assert.Equal(t, a.Sample(200, 0), slice.Sample(0, 0))
+
+	// samples are shared rather than copied.
+	slice.SetSample(0, 0, 0.5)
+	assert.Equal(t, slice.Sample(0, 0), a.Sample(200, 0))
+
+	assert.Equal(t, 0, a.Slice(revai.TimeRange{Start: time.Second, End: 2 * time.Second}).Frames())
+	assert.Equal(t, 0, a.Slice(revai.TimeRange{Start: 300 * time.Millisecond, End: 200 * time.Millisecond}).Frames())
+}
+
+func TestAudio_Quietest(t *testing.T) {
+	silent, err := New(Format{SampleRate: 8000, Channels: 2, BitsPerSample: 16}, time.Second)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// a tone with a pause from 600 to 650 milliseconds, silent from 605 to 645
+	// after the ramps of the tone.
+	a := silent.Redact([]revai.TimeRange{
+		{Start: 0, End: 600 * time.Millisecond},
+		{Start: 650 * time.Millisecond, End: time.Second},
+	}, &RedactOptions{Fill: Tone})
+
+	all := revai.TimeRange{Start: 0, End: time.Second}
+	assert.Equal(t, 625*time.Millisecond, a.Quietest(all, 40*time.Millisecond))
+
+	// only audio within the range is searched.
+	quiet := a.Quietest(revai.TimeRange{Start: 100 * time.Millisecond, End: 300 * time.Millisecond}, 20*time.Millisecond)
+	assert.True(t, quiet >= 110*time.Millisecond && quiet <= 290*time.Millisecond)
+
+	// windows longer than the range cover all of it.
+	assert.Equal(t, 200*time.Millisecond, a.Quietest(revai.TimeRange{Start: 100 * time.Millisecond, End: 300 * time.Millisecond}, time.Second))
+}
@@ -0,0 +1,372 @@
+package revai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WEROptions specifies how words are normalized before transcripts are
+// compared. By default words are lowercased and punctuation and disfluencies
+// such as "um" are removed.
+type WEROptions struct {
+	KeepCase         bool
+	KeepPunctuation  bool
+	KeepDisfluencies bool
+
+	// NumbersAsWords writes numbers such as 42 or 3.5% out in words so they
+	// match transcripts which spell them out.
+	NumbersAsWords bool
+
+	// Normalize replaces the normalization of the options above.
+	Normalize *NormalizeOptions
+}
+
+func (o *WEROptions) normalizeOptions() *NormalizeOptions {
+	if o == nil {
+		o = &WEROptions{}
+	}
+	if o.Normalize != nil {
+		return o.Normalize
+	}
+
+	opts := &NormalizeOptions{
+		RemoveTags:       true,
+		Lowercase:        !o.KeepCase,
+		StripPunctuation: !o.KeepPunctuation,
+		RemoveFillers:    !o.KeepDisfluencies,
+	}
+	if o.NumbersAsWords {
+		opts.Numbers = NumbersToWords
+	}
+	return opts
+}
+
+// AlignmentOp is the edit operation of an aligned word.
+type AlignmentOp string
+
+const (
+	AlignMatch        AlignmentOp = "match"
+	AlignSubstitution AlignmentOp = "substitution"
+	AlignInsertion    AlignmentOp = "insertion"
+	AlignDeletion     AlignmentOp = "deletion"
+)
+
+// AlignedWord is a reference word aligned with a hypothesis word. Reference is
+// empty for insertions and Hypothesis is empty for deletions.
+type AlignedWord struct {
+	Op         AlignmentOp `json:"op"`
+	Reference  string      `json:"reference,omitempty"`
+	Hypothesis string      `json:"hypothesis,omitempty"`
+
+	// Speaker is the reference speaker, or the hypothesis speaker when the
+	// reference has no speakers.
+	Speaker int `json:"speaker"`
+
+	// Ts and EndTs are the timing of the hypothesis word, or of the reference
+	// word for deletions.
+	Ts    float64 `json:"ts"`
+	EndTs float64 `json:"end_ts"`
+}
+
+func (a AlignedWord) String() string {
+	ts := formatCueTimestamp(secondsToDuration(a.Ts), ".")
+	switch a.Op {
+	case AlignSubstitution:
+		return fmt.Sprintf("%s S %s -> %s", ts, a.Reference, a.Hypothesis)
+	case AlignInsertion:
+		return fmt.Sprintf("%s I + %s", ts, a.Hypothesis)
+	case AlignDeletion:
+		return fmt.Sprintf("%s D - %s", ts, a.Reference)
+	default:
+		return fmt.Sprintf("%s   %s", ts, a.Reference)
+	}
+}
+
+// WERCounts are the edit counts of an alignment.
+type WERCounts struct {
+	ReferenceWords int `json:"reference_words"`
+	Matches        int `json:"matches"`
+	Substitutions  int `json:"substitutions"`
+	Insertions     int `json:"insertions"`
+	Deletions      int `json:"deletions"`
+}
+
+// WER returns the word error rate, the number of edits divided by the number
+// of reference words.
+func (c WERCounts) WER() float64 {
+	if c.ReferenceWords == 0 {
+		if c.Insertions == 0 {
+			return 0
+		}
+		return 1
+	}
+	return float64(c.Substitutions+c.Insertions+c.Deletions) / float64(c.ReferenceWords)
+}
+
+func (c *WERCounts) add(op AlignmentOp) {
+	switch op {
+	case AlignMatch:
+		c.Matches++
+		c.ReferenceWords++
+	case AlignSubstitution:
+		c.Substitutions++
+		c.ReferenceWords++
+	case AlignDeletion:
+		c.Deletions++
+		c.ReferenceWords++
+	case AlignInsertion:
+		c.Insertions++
+	}
+}
+
+// WERResult is the result of comparing a hypothesis with a reference.
+type WERResult struct {
+	WERCounts
+
+	// Speakers holds the counts of each speaker.
+	Speakers map[int]WERCounts `json:"speakers"`
+
+	Alignment []AlignedWord `json:"alignment"`
+}
+
+// Diff returns the alignment as text with a line per error.
+func (r *WERResult) Diff() string {
+	var b strings.Builder
+	for _, a := range r.Alignment {
+		if a.Op != AlignMatch {
+			b.WriteString(a.String())
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// werWord is a normalized word of a transcript being scored.
+type werWord struct {
+	text       string
+	speaker    int
+	ts, endTs  float64
+	hasSpeaker bool
+}
+
+// CompareTranscripts aligns the hypothesis with the reference transcript
+// and returns the word error rate and the alignment. A nil opts uses the
+// default normalization.
+func CompareTranscripts(reference, hypothesis *Transcript, opts *WEROptions) *WERResult {
+	return alignWords(transcriptWERWords(reference, opts), transcriptWERWords(hypothesis, opts))
+}
+
+// CompareText aligns the hypothesis with reference text and returns the word
+// error rate and the alignment. Reference words are given the speaker of the
+// hypothesis words they are aligned with.
+func CompareText(reference string, hypothesis *Transcript, opts *WEROptions) *WERResult {
+	var words []werWord
+	for _, text := range NormalizeText(reference, opts.normalizeOptions()) {
+		words = append(words, werWord{text: text})
+	}
+	return alignWords(words, transcriptWERWords(hypothesis, opts))
+}
+
+func transcriptWERWords(t *Transcript, opts *WEROptions) []werWord {
+	var words []werWord
+	for _, w := range t.Normalize(opts.normalizeOptions()) {
+		words = append(words, werWord{
+			text:       w.Text,
+			speaker:    w.Speaker,
+			ts:         w.Ts,
+			endTs:      w.EndTs,
+			hasSpeaker: true,
+		})
+	}
+	return words
+}
+
+// alignment operations, applied from the start of both word lists.
+const (
+	opDiagonal byte = iota
+	opUp
+	opLeft
+)
+
+// alignBacktraceCells is the largest table of operations backtraced
+// directly, larger alignments are split in half first so memory stays
+// linear in the number of words.
+const alignBacktraceCells = 1 << 16
+
+// alignWords aligns hyp with ref using the Levenshtein distance, preferring
+// matches and substitutions over insertions and deletions.
+func alignWords(ref, hyp []werWord) *WERResult {
+	var alignment []AlignedWord
+	i, j := 0, 0
+	for _, op := range alignOps(ref, hyp) {
+		var a AlignedWord
+		switch op {
+		case opDiagonal:
+			r, h := ref[i], hyp[j]
+			a = AlignedWord{Op: AlignMatch, Reference: r.text, Hypothesis: h.text, Speaker: r.speaker, Ts: h.ts, EndTs: h.endTs}
+			if r.text != h.text {
+				a.Op = AlignSubstitution
+			}
+			if !r.hasSpeaker {
+				a.Speaker = h.speaker
+			}
+			i++
+			j++
+		case opUp:
+			r := ref[i]
+			a = AlignedWord{Op: AlignDeletion, Reference: r.text, Speaker: -1, Ts: r.ts, EndTs: r.endTs}
+			if r.hasSpeaker {
+				a.Speaker = r.speaker
+			}
+			i++
+		default:
+			h := hyp[j]
+			a = AlignedWord{Op: AlignInsertion, Hypothesis: h.text, Speaker: h.speaker, Ts: h.ts, EndTs: h.endTs}
+			j++
+		}
+		alignment = append(alignment, a)
+	}
+
+	// deletions from reference text take the speaker and timing of the
+	// word before them, or of the first word for deletions at the start.
+	for i := range alignment {
+		if alignment[i].Speaker < 0 && i > 0 {
+			alignment[i].Speaker = alignment[i-1].Speaker
+			alignment[i].Ts = alignment[i-1].EndTs
+			alignment[i].EndTs = alignment[i-1].EndTs
+		}
+	}
+	for i := len(alignment) - 1; i >= 0; i-- {
+		if alignment[i].Speaker < 0 && i+1 < len(alignment) {
+			alignment[i].Speaker = alignment[i+1].Speaker
+			alignment[i].Ts = alignment[i+1].Ts
+			alignment[i].EndTs = alignment[i+1].Ts
+		}
+	}
+
+	result := &WERResult{Speakers: map[int]WERCounts{}, Alignment: alignment}
+	for _, a := range alignment {
+		result.add(a.Op)
+		counts := result.Speakers[a.Speaker]
+		counts.add(a.Op)
+		result.Speakers[a.Speaker] = counts
+	}
+
+	return result
+}
+
+// alignOps returns the operations aligning hyp with ref. Alignments too
+// large to backtrace are split at the middle of ref and the point of hyp the
+// best alignment passes through, as in Hirschberg's algorithm.
+func alignOps(ref, hyp []werWord) []byte {
+	n, m := len(ref), len(hyp)
+	if n < 2 || (n+1)*(m+1) <= alignBacktraceCells {
+		return alignBacktrace(ref, hyp)
+	}
+
+	mid := n / 2
+	forward := alignCosts(ref[:mid], hyp, false)
+	backward := alignCosts(ref[mid:], hyp, true)
+
+	split := 0
+	for k := 1; k <= m; k++ {
+		if forward[k]+backward[m-k] < forward[split]+backward[m-split] {
+			split = k
+		}
+	}
+
+	return append(alignOps(ref[:mid], hyp[:split]), alignOps(ref[mid:], hyp[split:])...)
+}
+
+// alignCosts returns the distances of ref to each prefix of hyp, or to each
+// suffix of hyp by its length when reversed.
+func alignCosts(ref, hyp []werWord, reversed bool) []int {
+	n, m := len(ref), len(hyp)
+	word := func(words []werWord, i int) string {
+		if reversed {
+			return words[len(words)-1-i].text
+		}
+		return words[i].text
+	}
+
+	prev := make([]int, m+1)
+	cur := make([]int, m+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= n; i++ {
+		cur[0] = i
+		for j := 1; j <= m; j++ {
+			cost := prev[j-1]
+			if word(ref, i-1) != word(hyp, j-1) {
+				cost++
+			}
+			if c := prev[j] + 1; c < cost {
+				cost = c
+			}
+			if c := cur[j-1] + 1; c < cost {
+				cost = c
+			}
+			cur[j] = cost
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// alignBacktrace returns the operations aligning hyp with ref from a table
+// of the operation chosen for each cell.
+func alignBacktrace(ref, hyp []werWord) []byte {
+	n, m := len(ref), len(hyp)
+
+	// back holds the operation chosen for each cell, costs only the
+	// previous and current rows.
+	back := make([]byte, (n+1)*(m+1))
+	prev := make([]int, m+1)
+	cur := make([]int, m+1)
+
+	for j := 1; j <= m; j++ {
+		prev[j] = j
+		back[j] = opLeft
+	}
+
+	for i := 1; i <= n; i++ {
+		cur[0] = i
+		back[i*(m+1)] = opUp
+		for j := 1; j <= m; j++ {
+			cost := prev[j-1]
+			if ref[i-1].text != hyp[j-1].text {
+				cost++
+			}
+			op := opDiagonal
+			if c := prev[j] + 1; c < cost {
+				cost, op = c, opUp
+			}
+			if c := cur[j-1] + 1; c < cost {
+				cost, op = c, opLeft
+			}
+			cur[j] = cost
+			back[i*(m+1)+j] = op
+		}
+		prev, cur = cur, prev
+	}
+
+	ops := make([]byte, 0, n+m)
+	for i, j := n, m; i > 0 || j > 0; {
+		op := back[i*(m+1)+j]
+		switch op {
+		case opDiagonal:
+			i--
+			j--
+		case opUp:
+			i--
+		default:
+			j--
+		}
+		ops = append(ops, op)
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
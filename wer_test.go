@@ -0,0 +1,121 @@
+package revai
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareTranscripts(t *testing.T) {
+	hypothesis := makeTestTranscript("um hello my game is Jane nice to meet you too")
+	hypothesis.Monologues[0].Speaker = 1
+
+	result := CompareTranscripts(testTranscript, hypothesis, nil)
+
+	assert.Equal(t, WERCounts{ReferenceWords: 9, Matches: 8, Substitutions: 1, Insertions: 1}, result.WERCounts)
+	assert.InDelta(t, 2.0/9.0, result.WER(), 1e-9)
+	assert.Equal(t, WERCounts{ReferenceWords: 5, Matches: 4, Substitutions: 1}, result.Speakers[0])
+	assert.Equal(t, WERCounts{ReferenceWords: 4, Matches: 4, Insertions: 1}, result.Speakers[1])
+
+	sub := result.Alignment[2]
+	assert.Equal(t, AlignSubstitution, sub.Op)
+	assert.Equal(t, "name", sub.Reference)
+	assert.Equal(t, "game", sub.Hypothesis)
+	assert.InDelta(t, 0.9, sub.Ts, 1e-9)
+	assert.Equal(t, "00:00:00.900 S name -> game\n00:00:03.000 I + too\n", result.Diff())
+}
+
+func TestCompareText(t *testing.T) {
+	result := CompareText("Hello, my name is Jane Doe. Nice to meet you!", testTranscript, nil)
+
+	assert.Equal(t, WERCounts{ReferenceWords: 10, Matches: 9, Deletions: 1}, result.WERCounts)
+	assert.Equal(t, AlignedWord{Op: AlignDeletion, Reference: "doe", Speaker: 0, Ts: 2.1, EndTs: 2.1}, result.Alignment[5])
+
+	assert.InDelta(t, 0.0, CompareText("", &Transcript{}, nil).WER(), 1e-9)
+}
+
+func TestCompareText_Normalization(t *testing.T) {
+	hypothesis := makeTestTranscript("I paid 1,200 dollars for 3.5% um")
+
+	result := CompareText("i paid one thousand two hundred dollars for three point five percent", hypothesis, &WEROptions{NumbersAsWords: true})
+	assert.Equal(t, 0.0, result.WER())
+
+	result = CompareText("I paid", makeTestTranscript("i paid um"), &WEROptions{KeepCase: true, KeepDisfluencies: true})
+	assert.Equal(t, WERCounts{ReferenceWords: 2, Matches: 1, Substitutions: 1, Insertions: 1}, result.WERCounts)
+}
+
+func TestCompareText_Long(t *testing.T) {
+	// long enough to be split before backtracing.
+	var ref, hyp []string
+	for i := 0; i < 1000; i++ {
+		w := "w" + strconv.Itoa(i)
+		ref = append(ref, w)
+		switch {
+		case i%7 == 0:
+		case i%11 == 0:
+			hyp = append(hyp, "x"+w)
+		case i%13 == 0:
+			hyp = append(hyp, w, "y")
+		default:
+			hyp = append(hyp, w)
+		}
+	}
+
+	result := CompareText(strings.Join(ref, " "), makeTestTranscript(strings.Join(hyp, " ")), nil)
+
+	var gotRef, gotHyp []string
+	for _, a := range result.Alignment {
+		if a.Reference != "" {
+			gotRef = append(gotRef, a.Reference)
+		}
+		if a.Hypothesis != "" {
+			gotHyp = append(gotHyp, a.Hypothesis)
+		}
+	}
+	assert.Equal(t, ref, gotRef)
+	assert.Equal(t, hyp, gotHyp)
+
+	// the split alignment is as short as the distance of the words.
+	refWords := make([]werWord, len(ref))
+	for i, w := range ref {
+		refWords[i] = werWord{text: w}
+	}
+	hypWords := make([]werWord, len(hyp))
+	for i, w := range hyp {
+		hypWords[i] = werWord{text: w}
+	}
+	edits := result.Substitutions + result.Insertions + result.Deletions
+	assert.Equal(t, alignCosts(refWords, hypWords, false)[len(hyp)], edits)
+	assert.Equal(t, 270, edits)
+}
+
+func TestStripPunctuation(t *testing.T) {
+	assert.Equal(t, "don't", stripPunctuation("don't"))
+	assert.Equal(t, "well-known", stripPunctuation("\"well-known\","))
+	assert.Equal(t, "", stripPunctuation("--"))
+}
+
+func TestNumberWords(t *testing.T) {
+	assert.Equal(t, "zero", strings.Join(numberWords(0), " "))
+	assert.Equal(t, "one thousand two hundred thirty four", strings.Join(numberWords(1234), " "))
+	assert.Equal(t, "two million five", strings.Join(numberWords(2000005), " "))
+
+	words, ok := spokenNumber("-3.05")
+	assert.True(t, ok)
+	assert.Equal(t, "minus three point zero five", strings.Join(words, " "))
+
+	for _, s := range []string{"12,34", "1.", "abc", "+4", "1.2.3", "-9223372036854775808", "9223372036854775808"} {
+		_, ok := spokenNumber(s)
+		assert.False(t, ok, s)
+	}
+
+	words, ok = spokenNumber("-9223372036854775807")
+	assert.True(t, ok)
+	assert.Equal(t, "minus", words[0])
+
+	// numbers beyond ±math.MaxInt64 are left as written.
+	assert.Equal(t, []string{"-9223372036854775808", "dollars"},
+		NormalizeText("-9223372036854775808 dollars", &NormalizeOptions{Numbers: NumbersToWords}))
+}